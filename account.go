@@ -2,9 +2,8 @@ package bitmarksdk
 
 import (
 	"bytes"
-	"net/http"
+	"fmt"
 	"strings"
-	"time"
 
 	"golang.org/x/crypto/sha3"
 )
@@ -17,78 +16,61 @@ const (
 )
 
 type Account struct {
-	api     *APIClient
+	client  *Client
 	seed    *Seed
 	AuthKey AuthKey
 	EncrKey EncrKey
 }
 
+// NewAccount creates an account and registers its encryption public key by the default client.
+//
+// Deprecated: use Client.CreateAccount.
 func NewAccount(network Network) (*Account, error) {
-	seed, err := NewSeed(SeedVersion1, network)
+	client, err := defaultClient(network)
 	if err != nil {
 		return nil, err
 	}
-
-	authKey, err := NewAuthKey(seed)
-	if err != nil {
-		return nil, err
-	}
-
-	encrKey, err := NewEncrKey(seed)
-	if err != nil {
-		return nil, err
-	}
-
-	apiClient := NewAPIClient(network, &http.Client{Timeout: 3 * time.Second})
-
-	account := &Account{apiClient, seed, authKey, encrKey}
-	err = account.api.registerEncPubkey(account)
-	if err != nil {
-		return nil, err
-	}
-	return account, nil
+	return client.CreateAccount()
 }
 
+// AccountFromCore restores the account of the network from the seed core.
+//
+// Deprecated: use Client.AccountFromCore, which rejects the accounts of another network.
 func AccountFromCore(network Network, core []byte) (*Account, error) {
-	seed := &Seed{SeedVersion1, network, core}
-
-	authKey, err := NewAuthKey(seed)
-	if err != nil {
-		return nil, err
+	if len(core) != seedCoreLength {
+		return nil, ErrSeedSizeMismatch
 	}
-
-	encrKey, err := NewEncrKey(seed)
-	if err != nil {
-		return nil, err
+	if network != Livenet && network != Testnet {
+		return nil, fmt.Errorf("unsupported network: %s", network)
 	}
 
-	apiClient := NewAPIClient(seed.network, &http.Client{Timeout: 3 * time.Second})
-
-	return &Account{apiClient, seed, authKey, encrKey}, nil
+	return newAccount(&Seed{SeedVersion1, network, core})
 }
 
+// AccountFromSeed restores the account of the seed, of either network.
+//
+// Deprecated: use Client.RestoreAccountFromSeed, which rejects the accounts of another network.
 func AccountFromSeed(s string) (*Account, error) {
 	seed, err := SeedFromBase58(s)
 	if err != nil {
 		return nil, err
 	}
 
-	authKey, err := NewAuthKey(seed)
-	if err != nil {
-		return nil, err
-	}
+	return newAccount(seed)
+}
 
-	encrKey, err := NewEncrKey(seed)
+// AccountFromRecoveryPhrase restores the account of the recovery phrase, of either network.
+//
+// Deprecated: use Client.RestoreAccountFromRecoveryPhrase, which rejects the accounts of another network.
+func AccountFromRecoveryPhrase(s string) (*Account, error) {
+	seed, err := seedFromRecoveryPhrase(s)
 	if err != nil {
 		return nil, err
 	}
-
-	apiClient := NewAPIClient(seed.network, &http.Client{Timeout: 3 * time.Second})
-
-	return &Account{apiClient, seed, authKey, encrKey}, nil
+	return newAccount(seed)
 }
 
-func AccountFromRecoveryPhrase(s string) (*Account, error) {
+func seedFromRecoveryPhrase(s string) (*Seed, error) {
 	b, err := phraseToBytes(strings.Split(s, " "))
 	if err != nil {
 		return nil, err
@@ -104,7 +86,31 @@ func AccountFromRecoveryPhrase(s string) (*Account, error) {
 		b[1:],
 	}
 
-	return AccountFromSeed(seed.String())
+	// validated as a seed string
+	return SeedFromBase58(seed.String())
+}
+
+func newAccount(seed *Seed) (*Account, error) {
+	authKey, err := NewAuthKey(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	encrKey, err := NewEncrKey(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Account{seed: seed, AuthKey: authKey, EncrKey: encrKey}, nil
+}
+
+// legacyClient returns the client the account is created by,
+// or the default client of the account network
+func (acct *Account) legacyClient() (*Client, error) {
+	if acct.client != nil {
+		return acct.client, nil
+	}
+	return defaultClient(acct.Network())
 }
 
 func (acct *Account) Network() Network {
@@ -0,0 +1,77 @@
+package bitmarksdk
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	ErrAccountNumberSizeMismatch     = errors.New("account number size mismatch")
+	ErrAccountNumberChecksumMismatch = errors.New("account number checksum mismatch")
+	ErrAccountNumberUnknownAlgorithm = errors.New("account number key algorithm unknown")
+)
+
+// AccountNumber is the decoded form of an account number, which consist of:
+//   - Key variant (1 byte)
+//   - Public key (32 bytes for ed25519)
+//   - Checksum (4 bytes)
+type AccountNumber struct {
+	Network   Network
+	Algorithm int
+	PublicKey []byte
+}
+
+func ParseAccountNumber(s string) (*AccountNumber, error) {
+	b := fromBase58(s)
+	if len(b) <= 1+checksumLength {
+		return nil, ErrAccountNumberSizeMismatch
+	}
+
+	keyVariant := b[0]
+	if keyVariant&pubkeyMask == 0 {
+		return nil, ErrAccountNumberUnknownAlgorithm
+	}
+
+	algorithm := int(keyVariant >> algorithmShift)
+	var keyLength int
+	switch algorithm {
+	case AlgEd25519:
+		keyLength = ed25519.PublicKeySize
+	default:
+		return nil, ErrAccountNumberUnknownAlgorithm
+	}
+
+	if len(b) != 1+keyLength+checksumLength {
+		return nil, ErrAccountNumberSizeMismatch
+	}
+
+	checksum := sha3.Sum256(b[:1+keyLength])
+	if !bytes.Equal(checksum[:checksumLength], b[1+keyLength:]) {
+		return nil, ErrAccountNumberChecksumMismatch
+	}
+
+	network := Livenet
+	if keyVariant&testnetMask != 0 {
+		network = Testnet
+	}
+
+	return &AccountNumber{network, algorithm, b[1 : 1+keyLength]}, nil
+}
+
+// String returns the base58 encoded account number.
+func (a *AccountNumber) String() string {
+	buffer := a.bytes()
+	checksum := sha3.Sum256(buffer)
+	return toBase58(append(buffer, checksum[:checksumLength]...))
+}
+
+func (a *AccountNumber) bytes() []byte {
+	keyVariant := byte(a.Algorithm<<algorithmShift) | pubkeyMask
+	if a.Network == Testnet {
+		keyVariant |= testnetMask
+	}
+	return append([]byte{keyVariant}, a.PublicKey...)
+}
@@ -1,8 +1,11 @@
 package bitmarksdk
 
 import (
+	"bytes"
 	"strings"
 	"testing"
+
+	"golang.org/x/crypto/sha3"
 )
 
 type valid struct {
@@ -69,3 +72,77 @@ func TestLivenetAccount(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestParseAccountNumber(t *testing.T) {
+	for _, data := range []valid{testnetData, livenetData} {
+		acct, _ := AccountFromSeed(data.seed)
+		an, err := ParseAccountNumber(acct.AccountNumber())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if an.Network != acct.Network() || an.Algorithm != AlgEd25519 ||
+			!bytes.Equal(an.PublicKey, acct.AuthKey.PublicKeyBytes()) || an.String() != acct.AccountNumber() {
+			t.Fatalf("unexpected account number: %+v", an)
+		}
+	}
+
+	acct, _ := AccountFromSeed(testnetData.seed)
+	valid := acct.bytes()
+	checksum := sha3.Sum256(valid)
+
+	tampered := append(append([]byte{}, valid...), checksum[:checksumLength]...)
+	tampered[10] ^= 0xff
+
+	unknownAlg := append([]byte{}, valid...)
+	unknownAlg[0] = byte(7<<algorithmShift) | pubkeyMask
+	unknownAlgChecksum := sha3.Sum256(unknownAlg)
+	unknownAlg = append(unknownAlg, unknownAlgChecksum[:checksumLength]...)
+
+	cases := map[string]error{
+		toBase58(tampered):   ErrAccountNumberChecksumMismatch,
+		toBase58(unknownAlg): ErrAccountNumberUnknownAlgorithm,
+		toBase58(append(valid[:20], checksum[:checksumLength]...)): ErrAccountNumberSizeMismatch,
+		"0OIl": ErrAccountNumberSizeMismatch,
+	}
+	for s, expected := range cases {
+		if _, err := ParseAccountNumber(s); err != expected {
+			t.Errorf("%s: expected %v, got %v", s, expected, err)
+		}
+	}
+}
+
+func TestRecordRejectsInvalidReceiver(t *testing.T) {
+	owner, _ := AccountFromSeed(testnetData.seed)
+	receiver, _ := AccountFromSeed(livenetData.seed)
+	typo := receiver.AccountNumber()[:10] + "x" + receiver.AccountNumber()[11:]
+
+	if _, err := NewTransferRecord(testBitmarkId, typo, owner); err != ErrAccountNumberChecksumMismatch {
+		t.Errorf("typo in receiver not detected: %v", err)
+	}
+	if _, err := NewTransferOffer(nil, testBitmarkId, typo, owner); err != ErrAccountNumberChecksumMismatch {
+		t.Errorf("typo in receiver not detected: %v", err)
+	}
+}
+
+func TestRecordRejectsInvalidOwner(t *testing.T) {
+	owner, _ := AccountFromSeed(testnetData.seed)
+	typo := owner.AccountNumber()[:10] + "x" + owner.AccountNumber()[11:]
+
+	issue, _ := NewIssueRecordWithNonce(strings.Repeat("0e", assetIndexLength), owner, 1)
+	issue.Owner = typo
+	if _, err := issue.Id(); err != ErrAccountNumberChecksumMismatch {
+		t.Errorf("issue owner: unexpected error: %v", err)
+	}
+
+	offer, _ := NewTransferOffer(nil, testBitmarkId, owner.AccountNumber(), owner)
+	offer.Owner = typo
+	if err := offer.VerifySignature(owner.AccountNumber()); err != ErrAccountNumberChecksumMismatch {
+		t.Errorf("offer receiver: unexpected error: %v", err)
+	}
+
+	ct := &CountersignedTransferRecord{testBitmarkId, typo, offer.Signature, offer.Signature}
+	if _, err := ct.Id(); err != ErrAccountNumberChecksumMismatch {
+		t.Errorf("countersigned owner: unexpected error: %v", err)
+	}
+}
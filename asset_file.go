@@ -2,9 +2,13 @@ package bitmarksdk
 
 import (
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"path/filepath"
 	"reflect"
+	"strings"
 
 	"golang.org/x/crypto/sha3"
 )
@@ -16,6 +20,18 @@ const (
 	Private Accessibility = "private"
 )
 
+var ErrUnknownFingerprintScheme = errors.New("unknown fingerprint scheme")
+
+// IntegrityError is returned when the content does not hash to the registered fingerprint.
+type IntegrityError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("content fingerprint mismatch: expected %s, got %s", e.Expected, e.Actual)
+}
+
 type AssetInfo struct {
 	Name     string
 	Metadata map[string]string
@@ -38,35 +54,63 @@ func NewAssetFileFromPath(path string, acs Accessibility) (*AssetFile, error) {
 		return nil, err
 	}
 
-	digest := sha3.Sum512(content)
-	fingerprint := "01" + hex.EncodeToString(digest[:])
-
 	return &AssetFile{
 		Path:          path,
 		Name:          filepath.Base(path),
 		Content:       content,
-		Fingerprint:   fingerprint,
+		Fingerprint:   fingerprintFromBytes(content),
 		Accessibility: acs,
 	}, nil
 }
 
 func NewAssetFile(name string, content []byte, acs Accessibility) *AssetFile {
-	digest := sha3.Sum512(content)
-	fingerprint := "01" + hex.EncodeToString(digest[:])
-
 	return &AssetFile{
 		Name:          name,
 		Content:       content,
-		Fingerprint:   fingerprint,
+		Fingerprint:   fingerprintFromBytes(content),
 		Accessibility: acs,
 	}
 }
 
 func (af *AssetFile) Id() string {
-	assetIndex := sha3.Sum512([]byte(af.Fingerprint))
+	return AssetIdFromFingerprint(af.Fingerprint)
+}
+
+// ComputeFingerprint computes the "01" + SHA3-512 fingerprint of the content read from r
+// without holding the whole content in memory.
+func ComputeFingerprint(r io.Reader) (string, error) {
+	h := sha3.New512()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return fingerprintSHA3Prefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// AssetIdFromFingerprint returns the asset id derived from the fingerprint.
+func AssetIdFromFingerprint(fingerprint string) string {
+	assetIndex := sha3.Sum512([]byte(fingerprint))
 	return hex.EncodeToString(assetIndex[:])
 }
 
+// VerifyFingerprint checks that the content hashes to the fingerprint.
+// ErrUnknownFingerprintScheme is returned if the fingerprint is not computed by "01" + SHA3-512.
+func VerifyFingerprint(fingerprint string, content []byte) error {
+	if !strings.HasPrefix(fingerprint, fingerprintSHA3Prefix) {
+		return ErrUnknownFingerprintScheme
+	}
+
+	actual := fingerprintFromBytes(content)
+	if actual != fingerprint {
+		return &IntegrityError{fingerprint, actual}
+	}
+	return nil
+}
+
+func fingerprintFromBytes(content []byte) string {
+	digest := sha3.Sum512(content)
+	return fingerprintSHA3Prefix + hex.EncodeToString(digest[:])
+}
+
 func (af *AssetFile) equivalent(asset *Asset) bool {
 	if af.propertyName == "" {
 		return true
@@ -0,0 +1,45 @@
+package bitmarksdk
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestComputeFingerprint(t *testing.T) {
+	content := bytes.Repeat([]byte("bitmark"), 100000)
+	af := NewAssetFile("test.txt", content, Public)
+
+	fingerprint, err := ComputeFingerprint(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fingerprint != af.Fingerprint {
+		t.Fatalf("expected %s, got %s", af.Fingerprint, fingerprint)
+	}
+
+	if AssetIdFromFingerprint(fingerprint) != af.Id() {
+		t.Fail()
+	}
+
+	if err := validateFingerprintFormat(fingerprint); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyFingerprint(t *testing.T) {
+	content := []byte("Hello, world!")
+	af := NewAssetFile("test.txt", content, Public)
+
+	if err := VerifyFingerprint(af.Fingerprint, content); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := VerifyFingerprint(af.Fingerprint, []byte("tampered")).(*IntegrityError); !ok {
+		t.Fatal("tampered content not detected")
+	}
+
+	if VerifyFingerprint("ffcustom", content) != ErrUnknownFingerprintScheme {
+		t.Fail()
+	}
+}
@@ -0,0 +1,223 @@
+package bitmarksdk
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	metadataSeparator = "\u0000"
+
+	fingerprintPrefixLength = 2
+	fingerprintSHA3Prefix   = "01"
+	fingerprintSHA3Length   = fingerprintPrefixLength + 128
+)
+
+type AssetField string
+
+const (
+	FieldName        AssetField = "name"
+	FieldFingerprint AssetField = "fingerprint"
+	FieldMetadata    AssetField = "metadata"
+)
+
+var (
+	ErrPropertyEmpty         = errors.New("not set")
+	ErrPropertyTooLong       = errors.New("exceeds the maximum length")
+	ErrPropertyInvalidUTF8   = errors.New("not valid UTF-8")
+	ErrPropertyNulChar       = errors.New("contains NUL character")
+	ErrMetadataEmptyKey      = errors.New("contains an empty key")
+	ErrMetadataDuplicateKey  = errors.New("contains a duplicate key")
+	ErrMetadataMalformed     = errors.New("malformed metadata")
+	ErrFingerprintPrefix     = errors.New("fingerprint prefix is not a 2-digit lowercase hex")
+	ErrFingerprintSHA3Digest = errors.New("fingerprint is not a SHA3-512 hex digest")
+)
+
+// AssetPropertyError describes a single violation of the asset property rules.
+// Key is set to the offending metadata key when Field is FieldMetadata.
+type AssetPropertyError struct {
+	Field AssetField
+	Key   string
+	Err   error
+}
+
+func (e *AssetPropertyError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("property %s[%q] %s", e.Field, e.Key, e.Err)
+	}
+	return fmt.Sprintf("property %s %s", e.Field, e.Err)
+}
+
+func (e *AssetPropertyError) Unwrap() error {
+	return e.Err
+}
+
+// AssetPropertyErrors collects every violation found by ValidateAssetProperties.
+type AssetPropertyErrors []*AssetPropertyError
+
+func (errs AssetPropertyErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Field returns the violations of the specified field.
+func (errs AssetPropertyErrors) Field(f AssetField) AssetPropertyErrors {
+	result := make(AssetPropertyErrors, 0)
+	for _, e := range errs {
+		if e.Field == f {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// ValidateAssetProperties checks the asset properties against the limits enforced by the chain.
+// Lengths are counted in bytes. All violations are returned at once as AssetPropertyErrors.
+func ValidateAssetProperties(name, fingerprint string, metadata map[string]string) error {
+	errs := make(AssetPropertyErrors, 0)
+
+	errs = append(errs, validateString(FieldName, name, minNameLength, maxNameLength)...)
+	errs = append(errs, validateString(FieldFingerprint, fingerprint, minFingerprintLength, maxFingerprintLength)...)
+	if len(fingerprint) >= minFingerprintLength {
+		if err := validateFingerprintFormat(fingerprint); err != nil {
+			errs = append(errs, &AssetPropertyError{Field: FieldFingerprint, Err: err})
+		}
+	}
+
+	errs = append(errs, validateMetadataParts(metadataParts(metadata))...)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ParseMetadata splits the compact metadata stored on the chain into key-value pairs.
+func ParseMetadata(compact string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if compact == "" {
+		return metadata, nil
+	}
+
+	parts := strings.Split(compact, metadataSeparator)
+	if errs := validateMetadataParts(parts); len(errs) > 0 {
+		return nil, errs
+	}
+
+	for i := 0; i < len(parts); i += 2 {
+		metadata[parts[i]] = parts[i+1]
+	}
+	return metadata, nil
+}
+
+func compactMetadata(metadata map[string]string) string {
+	return strings.Join(metadataParts(metadata), metadataSeparator)
+}
+
+// metadataParts flattens the metadata into key-value parts ordered by key
+func metadataParts(metadata map[string]string) []string {
+	parts := make([]string, 0, len(metadata)*2)
+	for _, key := range sortedKeys(metadata) {
+		parts = append(parts, key, metadata[key])
+	}
+	return parts
+}
+
+func validateString(field AssetField, s string, min, max int) AssetPropertyErrors {
+	errs := make(AssetPropertyErrors, 0)
+	switch {
+	case len(s) < min:
+		errs = append(errs, &AssetPropertyError{Field: field, Err: ErrPropertyEmpty})
+	case len(s) > max:
+		errs = append(errs, &AssetPropertyError{Field: field, Err: ErrPropertyTooLong})
+	}
+	if !utf8.ValidString(s) {
+		errs = append(errs, &AssetPropertyError{Field: field, Err: ErrPropertyInvalidUTF8})
+	}
+	return errs
+}
+
+func validateFingerprintFormat(fingerprint string) error {
+	if len(fingerprint) < fingerprintPrefixLength || !isLowerHex(fingerprint[:fingerprintPrefixLength]) {
+		return ErrFingerprintPrefix
+	}
+
+	if fingerprint[:fingerprintPrefixLength] == fingerprintSHA3Prefix {
+		if len(fingerprint) != fingerprintSHA3Length || !isLowerHex(fingerprint[fingerprintPrefixLength:]) {
+			return ErrFingerprintSHA3Digest
+		}
+	}
+	return nil
+}
+
+// validateMetadataParts checks the alternating key-value parts of the metadata
+func validateMetadataParts(parts []string) AssetPropertyErrors {
+	errs := make(AssetPropertyErrors, 0)
+	if len(parts)%2 != 0 {
+		return append(errs, &AssetPropertyError{Field: FieldMetadata, Err: ErrMetadataMalformed})
+	}
+
+	seen := make(map[string]bool)
+	length := 0
+	for i := 0; i < len(parts); i += 2 {
+		key, val := parts[i], parts[i+1]
+
+		if key == "" {
+			errs = append(errs, &AssetPropertyError{Field: FieldMetadata, Err: ErrMetadataEmptyKey})
+		}
+		if val == "" {
+			errs = append(errs, &AssetPropertyError{Field: FieldMetadata, Key: key, Err: ErrPropertyEmpty})
+		}
+		if strings.Contains(key, metadataSeparator) || strings.Contains(val, metadataSeparator) {
+			errs = append(errs, &AssetPropertyError{Field: FieldMetadata, Key: key, Err: ErrPropertyNulChar})
+		}
+		if !utf8.ValidString(key) || !utf8.ValidString(val) {
+			errs = append(errs, &AssetPropertyError{Field: FieldMetadata, Key: key, Err: ErrPropertyInvalidUTF8})
+		}
+		if seen[key] {
+			errs = append(errs, &AssetPropertyError{Field: FieldMetadata, Key: key, Err: ErrMetadataDuplicateKey})
+		}
+		seen[key] = true
+
+		length += len(key) + len(val)
+	}
+
+	if len(parts) > 1 {
+		length += len(parts) - 1 // separators
+	}
+	if length > maxMetadataLength {
+		errs = append(errs, &AssetPropertyError{Field: FieldMetadata, Err: ErrPropertyTooLong})
+	}
+	return errs
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isLowerHex(s string) bool {
+	if s == "" || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.DecodeString(padHex(s))
+	return err == nil
+}
+
+func padHex(s string) string {
+	if len(s)%2 != 0 {
+		return "0" + s
+	}
+	return s
+}
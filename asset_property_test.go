@@ -0,0 +1,92 @@
+package bitmarksdk
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const validFingerprint = "01" +
+	"1f2a0b9c3d4e5f60718293a4b5c6d7e8f90112233445566778899aabbccddeeff" +
+	"00112233445566778899aabbccddeeff00112233445566778899aabbccddeef"
+
+func TestValidateAssetProperties(t *testing.T) {
+	if err := ValidateAssetProperties("name", validFingerprint, map[string]string{"k": "v"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 22 three-byte characters exceed 64 bytes but not 64 runes
+	longName := strings.Repeat("名", 22)
+	err := ValidateAssetProperties(longName, "", map[string]string{
+		"":        "v",
+		"k\x00ey": "v",
+		"empty":   "",
+	})
+
+	errs, ok := err.(AssetPropertyErrors)
+	if !ok {
+		t.Fatalf("unexpected error type: %T", err)
+	}
+
+	if len(errs.Field(FieldName)) != 1 || !errors.Is(errs.Field(FieldName)[0], ErrPropertyTooLong) {
+		t.Errorf("name violation not reported: %v", errs)
+	}
+	if len(errs.Field(FieldFingerprint)) != 1 || !errors.Is(errs.Field(FieldFingerprint)[0], ErrPropertyEmpty) {
+		t.Errorf("fingerprint violation not reported: %v", errs)
+	}
+
+	expected := map[error]bool{ErrMetadataEmptyKey: false, ErrPropertyNulChar: false, ErrPropertyEmpty: false}
+	for _, e := range errs.Field(FieldMetadata) {
+		expected[e.Err] = true
+	}
+	for e, found := range expected {
+		if !found {
+			t.Errorf("metadata violation not reported: %v", e)
+		}
+	}
+}
+
+func TestValidateMetadataLength(t *testing.T) {
+	metadata := map[string]string{"k": strings.Repeat("é", maxMetadataLength/2)}
+	err := ValidateAssetProperties("name", validFingerprint, metadata)
+	if errs, ok := err.(AssetPropertyErrors); !ok || !errors.Is(errs[0], ErrPropertyTooLong) {
+		t.Fatalf("metadata length violation not reported: %v", err)
+	}
+}
+
+func TestValidateFingerprintFormat(t *testing.T) {
+	cases := map[string]error{
+		validFingerprint:                  nil,
+		"ff-custom-scheme":                nil,
+		"0Zabc":                           ErrFingerprintPrefix,
+		"01abc":                           ErrFingerprintSHA3Digest,
+		strings.ToUpper(validFingerprint): ErrFingerprintSHA3Digest,
+	}
+	for fingerprint, expected := range cases {
+		if err := validateFingerprintFormat(fingerprint); err != expected {
+			t.Errorf("%s: expected %v, got %v", fingerprint, expected, err)
+		}
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	metadata, err := ParseMetadata("a\x001\x00b\x002")
+	if err != nil || metadata["a"] != "1" || metadata["b"] != "2" {
+		t.Fatalf("unexpected result: %v %v", metadata, err)
+	}
+
+	_, err = ParseMetadata("a\x001\x00a\x002")
+	if errs, ok := err.(AssetPropertyErrors); !ok || errs[0].Err != ErrMetadataDuplicateKey {
+		t.Fatalf("duplicate key not reported: %v", err)
+	}
+
+	if _, err := ParseMetadata("a\x001\x00b"); err == nil {
+		t.Fatal("malformed metadata not reported")
+	}
+}
+
+func TestCompactMetadataOrder(t *testing.T) {
+	if compactMetadata(map[string]string{"b": "2", "a": "1"}) != "a\x001\x00b\x002" {
+		t.Fail()
+	}
+}
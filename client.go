@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 type Config struct {
@@ -12,40 +13,114 @@ type Config struct {
 
 	APIEndpoint string
 	KeyEndpoint string
+
+	// DataKeyAlgorithm is used to encrypt new private assets.
+	// ChaCha20-Poly1305 is used if not set.
+	DataKeyAlgorithm string
+
+	// KeyCache stores the verified encryption public keys.
+	// A cache of 1024 keys with a TTL of 10 minutes is used if not set.
+	KeyCache KeyCache
+
+	// SkipUnknownFingerprints disables the content verification of downloaded assets
+	// whose fingerprints are not computed by the schemes known to the SDK.
+	SkipUnknownFingerprints bool
+
+	// Observers are notified of the start and the end of every API request.
+	Observers []Observer
+
+	// RateLimiter limits the API requests, and can be shared by clients.
+	// The requests are not limited if not set.
+	RateLimiter *RateLimiter
 }
 
 type Client struct {
 	Network Network
+	profile *NetworkProfile
 	service *Service
+
+	skipUnknownFingerprints bool
 }
 
+// NewClient creates a client of the network registered by the name of cfg.Network.
+// It panics if the network is unknown or the config is invalid.
+// Use LookupNetwork and NewClientWithProfile to get the errors instead.
 func NewClient(cfg *Config) *Client {
-	var apiEndpoint string
-	var keyEndpoint string
-	var network Network
-	switch cfg.Network {
-	case "testnet":
-		apiEndpoint = "https://api.test.bitmark.com"
-		keyEndpoint = "https://key.assets.test.bitmark.com"
-		network = Testnet
-	case "livenet":
-		apiEndpoint = "https://api.bitmark.com"
-		keyEndpoint = "https://key.assets.bitmark.com"
-		network = Livenet
-	default:
-		panic("unsupported network")
+	profile, err := LookupNetwork(cfg.Network)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	c, err := NewClientWithProfile(profile, cfg)
+	if err != nil {
+		panic(err.Error())
 	}
+	return c
+}
+
+// NewClientWithProfile creates a client of the network described by the profile.
+// cfg.Network is ignored, and the endpoints of cfg override the ones of the profile.
+func NewClientWithProfile(profile *NetworkProfile, cfg *Config) (*Client, error) {
+	if profile == nil {
+		return nil, fmt.Errorf("%w: profile not set", ErrInvalidNetworkProfile)
+	}
+	if err := profile.validate(); err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	p := *profile
 
 	// allow endpoints customization
+	apiEndpoint := p.APIURL()
 	if cfg.APIEndpoint != "" {
 		apiEndpoint = cfg.APIEndpoint
 	}
+	keyEndpoint := p.KeyURL()
 	if cfg.KeyEndpoint != "" {
 		keyEndpoint = cfg.KeyEndpoint
 	}
+	assetEndpoint := p.AssetURL()
+	if p.AssetEndpoint == "" && cfg.APIEndpoint != "" {
+		assetEndpoint = cfg.APIEndpoint
+	}
 
-	svc := &Service{cfg.HTTPClient, apiEndpoint, keyEndpoint}
-	return &Client{network, svc}
+	dataKeyAlgorithm := AlgChaCha20Poly1305
+	if cfg.DataKeyAlgorithm != "" {
+		if _, err := lookupDataKeyAlgorithm(cfg.DataKeyAlgorithm); err != nil {
+			return nil, err
+		}
+		dataKeyAlgorithm = cfg.DataKeyAlgorithm
+	}
+
+	keyCache := cfg.KeyCache
+	if keyCache == nil {
+		keyCache = NewKeyCache(defaultKeyCacheSize, defaultKeyCacheTTL)
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	svc := &Service{
+		client:           httpClient,
+		apiEndpoint:      apiEndpoint,
+		keyEndpoint:      keyEndpoint,
+		assetEndpoint:    assetEndpoint,
+		dataKeyAlgorithm: dataKeyAlgorithm,
+		keyCache:         keyCache,
+		observers:        cfg.Observers,
+		limiter:          cfg.RateLimiter,
+	}
+	return &Client{Network: p.KeyNetwork, profile: &p, service: svc, skipUnknownFingerprints: cfg.SkipUnknownFingerprints}, nil
+}
+
+// Profile returns the profile of the network of the client.
+func (c *Client) Profile() NetworkProfile {
+	return *c.profile
 }
 
 func (c *Client) CreateAccount() (*Account, error) {
@@ -54,21 +129,15 @@ func (c *Client) CreateAccount() (*Account, error) {
 		return nil, err
 	}
 
-	authKey, err := NewAuthKey(seed)
+	account, err := newAccount(seed)
 	if err != nil {
 		return nil, err
 	}
 
-	encrKey, err := NewEncrKey(seed)
-	if err != nil {
-		return nil, err
-	}
-
-	account := &Account{seed: seed, AuthKey: authKey, EncrKey: encrKey}
-
 	if err := c.service.registerEncPubkey(account); err != nil {
 		return nil, err
 	}
+	account.client = c
 	return account, nil
 }
 
@@ -79,34 +148,46 @@ func (c *Client) RestoreAccountFromSeed(s string) (*Account, error) {
 	}
 
 	if seed.network != c.Network {
-		return nil, fmt.Errorf("trying to restore %s account in %s environment", seed.network, c.Network)
+		return nil, &NetworkMismatchError{c.Network, seed.network}
 	}
 
-	authKey, err := NewAuthKey(seed)
+	account, err := newAccount(seed)
 	if err != nil {
 		return nil, err
 	}
+	account.client = c
+	return account, nil
+}
 
-	encrKey, err := NewEncrKey(seed)
+// RestoreAccountFromRecoveryPhrase restores the account of the client network from the recovery phrase.
+func (c *Client) RestoreAccountFromRecoveryPhrase(phrase string) (*Account, error) {
+	seed, err := seedFromRecoveryPhrase(phrase)
 	if err != nil {
 		return nil, err
 	}
+	return c.RestoreAccountFromSeed(seed.String())
+}
+
+// AccountFromCore restores the account of the client network from the seed core.
+func (c *Client) AccountFromCore(core []byte) (*Account, error) {
+	if len(core) != seedCoreLength {
+		return nil, ErrSeedSizeMismatch
+	}
 
-	return &Account{seed: seed, AuthKey: authKey, EncrKey: encrKey}, nil
+	account, err := newAccount(&Seed{SeedVersion1, c.Network, core})
+	if err != nil {
+		return nil, err
+	}
+	account.client = c
+	return account, nil
 }
 
 func (c *Client) IssueByAssetFile(acct *Account, af *AssetFile, quantity int, info *AssetInfo) ([]string, error) {
-	var asset *AssetRecord
-
-	if info != nil {
-		var err error
-		asset, err = NewAssetRecord(info.Name, af.Fingerprint, info.Metadata, acct)
-		if err != nil {
-			return nil, err
-		}
+	if err := c.checkAccount(acct); err != nil {
+		return nil, err
 	}
 
-	issues, err := NewIssueRecords(af.Id(), acct, quantity)
+	asset, issues, err := newIssuance(acct, af.Fingerprint, info, quantity)
 	if err != nil {
 		return nil, err
 	}
@@ -119,17 +200,11 @@ func (c *Client) IssueByAssetFile(acct *Account, af *AssetFile, quantity int, in
 }
 
 func (c *Client) IssueByAssetFileWithNonces(acct *Account, af *AssetFile, info *AssetInfo, nonces []uint64) ([]string, error) {
-	var asset *AssetRecord
-
-	if info != nil {
-		var err error
-		asset, err = NewAssetRecord(info.Name, af.Fingerprint, info.Metadata, acct)
-		if err != nil {
-			return nil, err
-		}
+	if err := c.checkAccount(acct); err != nil {
+		return nil, err
 	}
 
-	issues, err := NewIssueRecords(af.Id(), acct, len(nonces), nonces...)
+	asset, issues, err := newIssuance(acct, af.Fingerprint, info, len(nonces), nonces...)
 	if err != nil {
 		return nil, err
 	}
@@ -141,7 +216,63 @@ func (c *Client) IssueByAssetFileWithNonces(acct *Account, af *AssetFile, info *
 	return bitmarkIds, err
 }
 
+// RegisterAsset registers an asset by its fingerprint without uploading the content,
+// and returns the asset id.
+func (c *Client) RegisterAsset(acct *Account, fingerprint string, info *AssetInfo) (string, error) {
+	if err := c.checkAccount(acct); err != nil {
+		return "", err
+	}
+
+	if info == nil {
+		return "", errors.New("asset info not set")
+	}
+
+	asset, err := NewAssetRecord(info.Name, fingerprint, info.Metadata, acct)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := c.service.createIssueTx(asset, nil); err != nil {
+		return "", err
+	}
+	return AssetIdFromFingerprint(fingerprint), nil
+}
+
+// IssueByFingerprint issues bitmarks of the asset identified by the fingerprint.
+// The asset content stays with the caller. If info is set, the asset is registered in the same request.
+func (c *Client) IssueByFingerprint(acct *Account, fingerprint string, quantity int, info *AssetInfo) ([]string, error) {
+	if err := c.checkAccount(acct); err != nil {
+		return nil, err
+	}
+
+	asset, issues, err := newIssuance(acct, fingerprint, info, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	bitmarkIds, err := c.service.createIssueTx(asset, issues)
+	return bitmarkIds, err
+}
+
+func (c *Client) IssueByFingerprintWithNonces(acct *Account, fingerprint string, info *AssetInfo, nonces []uint64) ([]string, error) {
+	if err := c.checkAccount(acct); err != nil {
+		return nil, err
+	}
+
+	asset, issues, err := newIssuance(acct, fingerprint, info, len(nonces), nonces...)
+	if err != nil {
+		return nil, err
+	}
+
+	bitmarkIds, err := c.service.createIssueTx(asset, issues)
+	return bitmarkIds, err
+}
+
 func (c *Client) IssueByAssetId(acct *Account, assetId string, quantity int) ([]string, error) {
+	if err := c.checkAccount(acct); err != nil {
+		return nil, err
+	}
+
 	issues, err := NewIssueRecords(assetId, acct, quantity)
 	if err != nil {
 		return nil, err
@@ -157,18 +288,21 @@ func (c *Client) Issue(asset *AssetRecord, issues []*IssueRecord) ([]string, err
 }
 
 func (c *Client) Transfer(acct *Account, bitmarkId, receiver string) (string, error) {
+	if err := c.checkAccount(acct); err != nil {
+		return "", err
+	}
+
+	if err := c.checkReceiver(receiver); err != nil {
+		return "", err
+	}
+
 	access, aerr := c.service.getAssetAccess(acct, bitmarkId)
 	if aerr != nil {
 		return "", aerr
 	}
 
 	if access.SessData != nil {
-		senderPublicKey, err := c.service.getEncPubkey(access.Sender)
-		if err != nil {
-			return "", err
-		}
-
-		dataKey, err := dataKeyFromSessionData(acct, access.SessData, senderPublicKey)
+		dataKey, err := c.dataKeyFromAccess(acct, access)
 		if err != nil {
 			return "", err
 		}
@@ -189,7 +323,7 @@ func (c *Client) Transfer(acct *Account, bitmarkId, receiver string) (string, er
 		}
 	}
 
-	bmk, err := c.service.getBitmark(bitmarkId)
+	bmk, err := c.service.getBitmark(bitmarkId, false)
 	if err != nil {
 		return "", err
 	}
@@ -207,18 +341,21 @@ func (c *Client) Transfer(acct *Account, bitmarkId, receiver string) (string, er
 }
 
 func (c *Client) SignTransferOffer(sender *Account, bitmarkId, receiver string, includeBitmark bool) (*TransferOfferRecord, error) {
+	if err := c.checkAccount(sender); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkReceiver(receiver); err != nil {
+		return nil, err
+	}
+
 	access, aerr := c.service.getAssetAccess(sender, bitmarkId)
 	if aerr != nil {
 		return nil, aerr
 	}
 
 	if access.SessData != nil {
-		senderPublicKey, err := c.service.getEncPubkey(access.Sender)
-		if err != nil {
-			return nil, err
-		}
-
-		dataKey, err := dataKeyFromSessionData(sender, access.SessData, senderPublicKey)
+		dataKey, err := c.dataKeyFromAccess(sender, access)
 		if err != nil {
 			return nil, err
 		}
@@ -239,7 +376,7 @@ func (c *Client) SignTransferOffer(sender *Account, bitmarkId, receiver string,
 		}
 	}
 
-	bmk, err := c.service.getBitmark(bitmarkId)
+	bmk, err := c.service.getBitmark(bitmarkId, false)
 	if err != nil {
 		return nil, err
 	}
@@ -255,23 +392,159 @@ func (c *Client) SignTransferOffer(sender *Account, bitmarkId, receiver string,
 }
 
 func (c *Client) SubmitTransferOffer(sender *Account, t *TransferOfferRecord, extraInfo interface{}) (string, error) {
+	if err := c.checkAccount(sender); err != nil {
+		return "", err
+	}
+
 	return c.service.submitTransferOffer(sender, t, extraInfo)
 }
 
 func (c *Client) GetTransferOffer(sender *Account, offerId string) (*TransferOffer, error) {
+	if err := c.checkAccount(sender); err != nil {
+		return nil, err
+	}
+
 	return c.service.getTransferOffer(sender, offerId)
 }
 
-func (c *Client) CompleteTransferOffer(sender *Account, offerId, action, countersignature string) (string, error) {
+// ListTransferOffers lists the offers sent to (OfferIncoming) or by (OfferOutgoing) the account.
+// All offers are listed if status is empty.
+func (c *Client) ListTransferOffers(acct *Account, direction TransferOfferDirection, status TransferOfferStatus) ([]*TransferOffer, error) {
+	if err := c.checkAccount(acct); err != nil {
+		return nil, err
+	}
+
+	if direction != OfferIncoming && direction != OfferOutgoing {
+		return nil, fmt.Errorf("%w: %q", ErrOfferDirection, direction)
+	}
+
+	return c.service.listTransferOffers(acct, direction, status)
+}
+
+func (c *Client) CompleteTransferOffer(sender *Account, offerId string, action TransferOfferAction, countersignature string) (string, error) {
+	if err := c.checkAccount(sender); err != nil {
+		return "", err
+	}
+
 	return c.service.completeTransferOffer(sender, offerId, action, countersignature)
 }
 
+// AcceptTransferOffer countersigns the offer by the receiver and completes it.
+func (c *Client) AcceptTransferOffer(receiver *Account, offerId string) (string, error) {
+	if err := c.checkAccount(receiver); err != nil {
+		return "", err
+	}
+
+	offer, err := c.service.getTransferOffer(receiver, offerId)
+	if err != nil {
+		return "", err
+	}
+	if offer == nil {
+		return "", errors.New("transfer offer not found")
+	}
+
+	sender, err := c.inspectTransferOffer(offer)
+	if err != nil {
+		return "", err
+	}
+
+	record, err := offer.Record.countersign(receiver, sender)
+	if err != nil {
+		return "", err
+	}
+
+	return c.service.completeTransferOffer(receiver, offerId, ActionAccept, record.Countersignature)
+}
+
+// InspectTransferOffer checks the offer against the registry. The offer passes if
+// it is signed by the current owner of a confirmed bitmark and links to the bitmark head.
+func (c *Client) InspectTransferOffer(offer *TransferOffer) error {
+	_, err := c.inspectTransferOffer(offer)
+	return err
+}
+
+// inspectTransferOffer returns the sender of the inspected offer
+func (c *Client) inspectTransferOffer(offer *TransferOffer) (string, error) {
+	bitmarkId, sender := offer.BitmarkId, offer.From
+	if embedded := offer.Record.Bitmark; embedded != nil {
+		if bitmarkId == "" {
+			bitmarkId = embedded.Id
+		}
+		if sender == "" {
+			sender = embedded.Owner
+		}
+	}
+	if bitmarkId == "" {
+		return "", ErrOfferBitmarkUnknown
+	}
+
+	bmk, err := c.service.getBitmark(bitmarkId, true)
+	if err != nil {
+		return "", err
+	}
+	if bmk.Status == TxPending {
+		return "", ErrOfferBitmarkPending
+	}
+
+	if sender == "" {
+		sender = bmk.Owner
+	}
+	if sender != bmk.Owner {
+		return "", ErrOfferSenderNotOwner
+	}
+
+	if err := offer.Record.VerifySignature(sender); err != nil {
+		return "", err
+	}
+
+	if offer.Record.Link != bmk.HeadId {
+		return "", ErrOfferStaleLink
+	}
+
+	if embedded := offer.Record.Bitmark; embedded != nil {
+		if embedded.Id != bmk.Id || embedded.HeadId != offer.Record.Link {
+			return "", ErrOfferBitmarkMismatch
+		}
+	}
+
+	return sender, nil
+}
+
+func (c *Client) RejectTransferOffer(receiver *Account, offerId string) error {
+	if err := c.checkAccount(receiver); err != nil {
+		return err
+	}
+
+	_, err := c.service.completeTransferOffer(receiver, offerId, ActionReject, "")
+	return err
+}
+
+func (c *Client) CancelTransferOffer(sender *Account, offerId string) error {
+	if err := c.checkAccount(sender); err != nil {
+		return err
+	}
+
+	_, err := c.service.completeTransferOffer(sender, offerId, ActionCancel, "")
+	return err
+}
+
 func (c *Client) CountersignedTransfer(t *CountersignedTransferRecord) (string, error) {
 	return c.service.createCountersignTransferTx(t)
 }
 
+// CountersignTransfer inspects the offer, which must embed the bitmark, against the registry
+// before countersigning it by the receiver.
 func (c *Client) CountersignTransfer(receiver *Account, t *TransferOfferRecord) (string, error) {
-	record, err := t.Countersign(receiver)
+	if err := c.checkAccount(receiver); err != nil {
+		return "", err
+	}
+
+	sender, err := c.inspectTransferOffer(&TransferOffer{Record: *t})
+	if err != nil {
+		return "", err
+	}
+
+	record, err := t.countersign(receiver, sender)
 	if err != nil {
 		return "", err
 	}
@@ -279,6 +552,10 @@ func (c *Client) CountersignTransfer(receiver *Account, t *TransferOfferRecord)
 }
 
 func (c *Client) DownloadAsset(acct *Account, bitmarkId string) (string, []byte, error) {
+	if err := c.checkAccount(acct); err != nil {
+		return "", nil, err
+	}
+
 	access, err := c.service.getAssetAccess(acct, bitmarkId)
 	if err != nil {
 		return "", nil, err
@@ -289,43 +566,92 @@ func (c *Client) DownloadAsset(acct *Account, bitmarkId string) (string, []byte,
 		return "", nil, err
 	}
 
-	if access.SessData == nil { // public asset
-		return fileName, content, nil
-	}
+	if access.SessData != nil { // private asset
+		dataKey, err := c.dataKeyFromAccess(acct, access)
+		if err != nil {
+			return "", nil, err
+		}
 
-	encrPubkey, err := c.service.getEncPubkey(access.Sender)
-	if err != nil {
-		return "", nil, fmt.Errorf("fail to get enc public key: %s", err.Error())
+		content, err = dataKey.Decrypt(content)
+		if err != nil {
+			return "", nil, err
+		}
 	}
 
-	dataKey, err := dataKeyFromSessionData(acct, access.SessData, encrPubkey)
+	bmk, err := c.service.getBitmark(bitmarkId, false)
 	if err != nil {
 		return "", nil, err
 	}
 
-	plaintext, err := dataKey.Decrypt(content)
-	if err != nil {
+	if err := c.verifyContent(bmk.Asset.Fingerprint, content); err != nil {
 		return "", nil, err
 	}
 
-	return fileName, plaintext, nil
+	return fileName, content, nil
 }
 
+// RentBitmark grants the renter access to the private asset for the specified days.
+// The lessor can be any owner of the bitmark, not only the one who uploaded the asset.
 func (c *Client) RentBitmark(lessor *Account, bitmarkId, receiver string, days uint) error {
-	access, err := c.service.getAssetAccess(lessor, bitmarkId)
+	if err := c.checkAccount(lessor); err != nil {
+		return err
+	}
+
+	if err := c.checkReceiver(receiver); err != nil {
+		return err
+	}
+
+	dataKey, err := c.leasableDataKey(lessor, bitmarkId)
 	if err != nil {
 		return err
 	}
-	if access.SessData == nil {
-		return errors.New("no need to rent public assets")
+
+	return c.grantLease(lessor, dataKey, bitmarkId, receiver, days)
+}
+
+// RentBitmarkToMany grants the renters access to the private asset for the specified days.
+// The renters failed to be granted are reported by BulkLeaseError.
+func (c *Client) RentBitmarkToMany(lessor *Account, bitmarkId string, renters []string, days uint) error {
+	if err := c.checkAccount(lessor); err != nil {
+		return err
 	}
 
-	dataKey, err := dataKeyFromSessionData(lessor, access.SessData, lessor.EncrKey.PublicKeyBytes())
+	dataKey, err := c.leasableDataKey(lessor, bitmarkId)
 	if err != nil {
 		return err
 	}
 
-	recipientEncrPubkey, err := c.service.getEncPubkey(receiver)
+	errs := make(BulkLeaseError)
+	for _, renter := range renters {
+		if err := c.grantLease(lessor, dataKey, bitmarkId, renter, days); err != nil {
+			errs[renter] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c *Client) leasableDataKey(lessor *Account, bitmarkId string) (DataKey, error) {
+	access, err := c.service.getAssetAccess(lessor, bitmarkId)
+	if err != nil {
+		return nil, err
+	}
+	if access.SessData == nil {
+		return nil, errors.New("no need to rent public assets")
+	}
+
+	return c.dataKeyFromAccess(lessor, access)
+}
+
+func (c *Client) grantLease(lessor *Account, dataKey DataKey, bitmarkId, renter string, days uint) error {
+	if err := c.checkReceiver(renter); err != nil {
+		return err
+	}
+
+	recipientEncrPubkey, err := c.service.getEncPubkey(renter)
 	if err != nil {
 		return err
 	}
@@ -335,25 +661,82 @@ func (c *Client) RentBitmark(lessor *Account, bitmarkId, receiver string, days u
 		return err
 	}
 
-	return c.service.updateLease(lessor, bitmarkId, receiver, days, data)
+	return c.service.updateLease(lessor, bitmarkId, renter, days, data)
+}
+
+// RenewLease extends the lease granted to the renter by the specified days,
+// looking it up among the leases granted by the lessor. LeaseNotFoundError is
+// returned if there is none. The new expiry is rounded to the nearest day.
+func (c *Client) RenewLease(lessor *Account, bitmarkId, renter string, days uint) error {
+	if err := c.checkAccount(lessor); err != nil {
+		return err
+	}
+
+	leases, err := c.service.listGrantedLeases(lessor)
+	if err != nil {
+		return err
+	}
+
+	for _, lease := range leases {
+		if lease.BitmarkId == bitmarkId && lease.Renter == renter {
+			return c.RenewGrantedLease(lessor, lease, days)
+		}
+	}
+	return &LeaseNotFoundError{bitmarkId, renter}
+}
+
+// RenewGrantedLease extends a lease listed by ListGrantedLeases by the specified days.
+// The new expiry is rounded to the nearest day.
+func (c *Client) RenewGrantedLease(lessor *Account, lease *Lease, days uint) error {
+	return c.RentBitmark(lessor, lease.BitmarkId, lease.Renter, lease.renewalDays(days))
+}
+
+func (c *Client) RevokeLease(lessor *Account, bitmarkId, renter string) error {
+	if err := c.checkAccount(lessor); err != nil {
+		return err
+	}
+
+	return c.service.revokeLease(lessor, bitmarkId, renter)
 }
 
-func (c *Client) ListLeases(renter *Account) ([]accessByRenting, error) {
+// ListLeases lists the leases rented by the account.
+func (c *Client) ListLeases(renter *Account) ([]*Lease, error) {
+	if err := c.checkAccount(renter); err != nil {
+		return nil, err
+	}
+
 	return c.service.listLeases(renter)
 }
 
-func (c *Client) DownloadAssetByLease(acct *Account, access *accessByRenting) ([]byte, error) {
-	_, content, err := c.service.getAssetContent(access.URL)
+// ListGrantedLeases lists the leases granted by the account as the lessor.
+func (c *Client) ListGrantedLeases(lessor *Account) ([]*Lease, error) {
+	if err := c.checkAccount(lessor); err != nil {
+		return nil, err
+	}
+
+	return c.service.listGrantedLeases(lessor)
+}
+
+func (c *Client) DownloadAssetByLease(acct *Account, lease *Lease) ([]byte, error) {
+	if err := c.checkAccount(acct); err != nil {
+		return nil, err
+	}
+
+	if lease.Expired() {
+		return nil, &LeaseExpiredError{lease.BitmarkId, lease.ExpiresAt}
+	}
+
+	_, content, err := c.service.getAssetContent(lease.URL)
 	if err != nil {
 		return nil, err
 	}
 
-	encrPubkey, err := acct.api.getEncPubkey(access.Owner)
+	encrPubkey, err := c.service.getEncPubkey(lease.Owner)
 	if err != nil {
 		return nil, fmt.Errorf("fail to get enc public key: %s", err.Error())
 	}
 
-	dataKey, err := dataKeyFromSessionData(acct, access.SessData, encrPubkey)
+	dataKey, err := dataKeyFromSessionData(acct, lease.SessData, encrPubkey)
 	if err != nil {
 		return nil, err
 	}
@@ -363,6 +746,15 @@ func (c *Client) DownloadAssetByLease(acct *Account, access *accessByRenting) ([
 		return nil, err
 	}
 
+	asset, err := c.service.getAsset(lease.AssetId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.verifyContent(asset.Fingerprint, plaintext); err != nil {
+		return nil, err
+	}
+
 	return plaintext, nil
 }
 
@@ -371,5 +763,74 @@ func (c *Client) QueryBitmarks(filter *BitmarkFilter) ([]*Bitmark, error) {
 }
 
 func (c *Client) GetBitmark(bitmarkId string) (*Bitmark, error) {
-	return c.service.getBitmark(bitmarkId)
+	return c.service.getBitmark(bitmarkId, false)
+}
+
+// GetPendingBitmark gets the bitmark including its pending transactions.
+func (c *Client) GetPendingBitmark(bitmarkId string) (*Bitmark, error) {
+	return c.service.getBitmark(bitmarkId, true)
+}
+
+func (c *Client) GetAsset(assetId string) (*Asset, error) {
+	return c.service.getAsset(assetId)
+}
+
+// checkAccount validates the account and its network
+func (c *Client) checkAccount(acct *Account) error {
+	if acct == nil {
+		return ErrInvalidAccount
+	}
+	if acct.Network() != c.Network {
+		return &NetworkMismatchError{c.Network, acct.Network()}
+	}
+	return nil
+}
+
+// checkReceiver validates the account number and its network
+func (c *Client) checkReceiver(acctNo string) error {
+	an, err := ParseAccountNumber(acctNo)
+	if err != nil {
+		return err
+	}
+	if an.Network != c.Network {
+		return &NetworkMismatchError{c.Network, an.Network}
+	}
+	return nil
+}
+
+// dataKeyFromAccess recovers the data key from the session data held by the account,
+// which is encrypted by the sender of the session data
+func (c *Client) dataKeyFromAccess(acct *Account, access *accessByOwnership) (DataKey, error) {
+	senderPublicKey, err := c.service.getEncPubkey(access.Sender)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get enc public key: %s", err.Error())
+	}
+
+	return dataKeyFromSessionData(acct, access.SessData, senderPublicKey)
+}
+
+func (c *Client) verifyContent(fingerprint string, content []byte) error {
+	err := VerifyFingerprint(fingerprint, content)
+	if err == ErrUnknownFingerprintScheme && c.skipUnknownFingerprints {
+		return nil
+	}
+	return err
+}
+
+func newIssuance(acct *Account, fingerprint string, info *AssetInfo, quantity int, nonces ...uint64) (*AssetRecord, []*IssueRecord, error) {
+	var asset *AssetRecord
+
+	if info != nil {
+		var err error
+		asset, err = NewAssetRecord(info.Name, fingerprint, info.Metadata, acct)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	issues, err := NewIssueRecords(AssetIdFromFingerprint(fingerprint), acct, quantity, nonces...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return asset, issues, nil
 }
@@ -0,0 +1,449 @@
+package bitmarksdk
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const testBitmarkId = "ba9d57354f0a1847be3e6c3f7e96068c2015b8503cbdeaecaf553ff776371aea"
+
+func newTestClient(handler http.Handler, cfg *Config) (*Client, *httptest.Server) {
+	ts := httptest.NewServer(handler)
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	cfg.HTTPClient = ts.Client()
+	cfg.Network = "testnet"
+	cfg.APIEndpoint = ts.URL
+	cfg.KeyEndpoint = ts.URL
+	return NewClient(cfg), ts
+}
+
+func newTestAccount(network Network) *Account {
+	seed, err := NewSeed(SeedVersion1, network)
+	if err != nil {
+		panic(err)
+	}
+	acct, err := AccountFromSeed(seed.String())
+	if err != nil {
+		panic(err)
+	}
+	return acct
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// encPubkeyHandler serves the encryption public key of acct signed by signer
+func encPubkeyHandler(acct, signer *Account) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"encryption_pubkey": hex.EncodeToString(acct.EncrKey.PublicKeyBytes()),
+			"signature":         hex.EncodeToString(signer.AuthKey.Sign(acct.EncrKey.PublicKeyBytes())),
+		})
+	})
+}
+
+func publicAssetHandler(served []byte, fingerprint string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/bitmarks/"+testBitmarkId+"/asset", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"url": "http://" + r.Host + "/content"})
+	})
+	mux.HandleFunc("/content", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="test.txt"`)
+		w.Write(served)
+	})
+	mux.HandleFunc("/v1/bitmarks/"+testBitmarkId, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"bitmark": map[string]interface{}{"id": testBitmarkId},
+			"asset":   map[string]interface{}{"fingerprint": fingerprint},
+		})
+	})
+	return mux
+}
+
+func TestDownloadAssetVerification(t *testing.T) {
+	owner, _ := AccountFromSeed(testnetData.seed)
+	content := []byte("Hello, world!")
+	fingerprint := NewAssetFile("test.txt", content, Public).Fingerprint
+
+	client, ts := newTestClient(publicAssetHandler(content, fingerprint), nil)
+	name, downloaded, err := client.DownloadAsset(owner, testBitmarkId)
+	ts.Close()
+	if err != nil || name != "test.txt" || string(downloaded) != string(content) {
+		t.Fatalf("unexpected result: %s %s %v", name, downloaded, err)
+	}
+
+	client, ts = newTestClient(publicAssetHandler([]byte("tampered"), fingerprint), nil)
+	_, _, err = client.DownloadAsset(owner, testBitmarkId)
+	ts.Close()
+	if _, ok := err.(*IntegrityError); !ok {
+		t.Fatalf("tampered content not detected: %v", err)
+	}
+
+	client, ts = newTestClient(publicAssetHandler(content, "ffcustom"), nil)
+	_, _, err = client.DownloadAsset(owner, testBitmarkId)
+	ts.Close()
+	if err != ErrUnknownFingerprintScheme {
+		t.Fatalf("unknown scheme not reported: %v", err)
+	}
+
+	client, ts = newTestClient(publicAssetHandler(content, "ffcustom"), &Config{SkipUnknownFingerprints: true})
+	_, _, err = client.DownloadAsset(owner, testBitmarkId)
+	ts.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+type issueRequestBody struct {
+	Assets []*AssetRecord `json:"assets"`
+	Issues []*IssueRecord `json:"issues"`
+}
+
+// issueHandler records the body of the issue request and answers with a tx per issue
+func issueHandler(body *issueRequestBody) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1/issue" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(body)
+
+		txs := make([]map[string]string, 0)
+		for _, issue := range body.Issues {
+			id, _ := issue.Id()
+			txs = append(txs, map[string]string{"txId": id})
+		}
+		writeJSON(w, txs)
+	})
+}
+
+func TestRegisterAsset(t *testing.T) {
+	registrant, _ := AccountFromSeed(testnetData.seed)
+	info := &AssetInfo{Name: "contract", Metadata: map[string]string{"type": "pdf"}}
+
+	for _, fingerprint := range []string{validFingerprint, "ffcustom"} {
+		var body issueRequestBody
+		client, ts := newTestClient(issueHandler(&body), nil)
+		assetId, err := client.RegisterAsset(registrant, fingerprint, info)
+		ts.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", fingerprint, err)
+		}
+		if assetId != AssetIdFromFingerprint(fingerprint) {
+			t.Errorf("%s: unexpected asset id %s", fingerprint, assetId)
+		}
+		if len(body.Assets) != 1 || body.Assets[0].Fingerprint != fingerprint || len(body.Issues) != 0 {
+			t.Errorf("%s: unexpected request %+v", fingerprint, body)
+		}
+	}
+
+	// the asset info is required, and a malformed fingerprint is rejected before the request
+	client, ts := newTestClient(http.NotFoundHandler(), nil)
+	defer ts.Close()
+	if _, err := client.RegisterAsset(registrant, validFingerprint, nil); err == nil {
+		t.Error("registered without the asset info")
+	}
+	if _, err := client.RegisterAsset(registrant, "01abc", info); err == nil {
+		t.Error("malformed fingerprint registered")
+	}
+}
+
+func TestIssueByFingerprint(t *testing.T) {
+	owner, _ := AccountFromSeed(testnetData.seed)
+	info := &AssetInfo{Name: "contract"}
+
+	var body issueRequestBody
+	client, ts := newTestClient(issueHandler(&body), nil)
+	bitmarkIds, err := client.IssueByFingerprint(owner, validFingerprint, 3, info)
+	ts.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bitmarkIds) != 3 || len(body.Assets) != 1 || len(body.Issues) != 3 {
+		t.Fatalf("unexpected issuance: %v %+v", bitmarkIds, body)
+	}
+	for _, issue := range body.Issues {
+		if issue.AssetIndex != AssetIdFromFingerprint(validFingerprint) {
+			t.Errorf("unexpected asset %s", issue.AssetIndex)
+		}
+	}
+
+	// without the info only the issues of a registered asset are sent
+	body = issueRequestBody{}
+	client, ts = newTestClient(issueHandler(&body), nil)
+	bitmarkIds, err = client.IssueByFingerprintWithNonces(owner, validFingerprint, nil, []uint64{7, 8})
+	ts.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bitmarkIds) != 2 || len(body.Assets) != 0 || body.Issues[0].Nonce != 7 {
+		t.Fatalf("unexpected issuance: %v %+v", bitmarkIds, body)
+	}
+}
+
+func TestIssueByUnknownFingerprint(t *testing.T) {
+	owner, _ := AccountFromSeed(testnetData.seed)
+	content := []byte("Hello, world!")
+
+	// a fingerprint of an unknown scheme is issued as it is
+	var body issueRequestBody
+	client, ts := newTestClient(issueHandler(&body), nil)
+	bitmarkIds, err := client.IssueByFingerprint(owner, "ffcustom", 1, &AssetInfo{Name: "custom"})
+	ts.Close()
+	if err != nil || len(bitmarkIds) != 1 || body.Assets[0].Fingerprint != "ffcustom" {
+		t.Fatalf("unexpected issuance: %v %+v %v", bitmarkIds, body, err)
+	}
+
+	// its content can't be verified unless the unknown schemes are skipped
+	client, ts = newTestClient(publicAssetHandler(content, "ffcustom"), nil)
+	_, _, err = client.DownloadAsset(owner, testBitmarkId)
+	ts.Close()
+	if err != ErrUnknownFingerprintScheme {
+		t.Fatalf("unknown scheme not reported: %v", err)
+	}
+
+	client, ts = newTestClient(publicAssetHandler(content, "ffcustom"), &Config{SkipUnknownFingerprints: true})
+	_, downloaded, err := client.DownloadAsset(owner, testBitmarkId)
+	ts.Close()
+	if err != nil || string(downloaded) != string(content) {
+		t.Fatalf("unexpected result: %s %v", downloaded, err)
+	}
+}
+
+func TestAcceptTransferOffer(t *testing.T) {
+	sender, _ := AccountFromSeed(testnetData.seed)
+	receiver, _ := AccountFromSeed("5XEECscX3EQvpqMH59Es92uE9KXuuFRQ5pmZsQtyJFiqLEEi7CqSpCo")
+
+	offerRecord, err := NewTransferOffer(nil, testBitmarkId, receiver.AccountNumber(), sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := offerRecord.countersign(receiver, sender.AccountNumber())
+
+	var reply map[string]string
+	mux := http.NewServeMux()
+	mux.Handle("/v1/bitmarks/"+testBitmarkId, bitmarkHandler(&Bitmark{Id: testBitmarkId, HeadId: testBitmarkId, Owner: sender.AccountNumber()}))
+	mux.HandleFunc("/v2/transfer_offers", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			writeJSON(w, map[string]interface{}{
+				"offer": &TransferOffer{Id: "offer", BitmarkId: testBitmarkId, From: sender.AccountNumber(), Status: OfferStatusOpen, Record: *offerRecord},
+			})
+		case "PATCH":
+			var body struct {
+				Reply map[string]string `json:"reply"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			reply = body.Reply
+			writeJSON(w, map[string]string{"tx_id": "tx"})
+		}
+	})
+
+	client, ts := newTestClient(mux, nil)
+	defer ts.Close()
+
+	txId, err := client.AcceptTransferOffer(receiver, "offer")
+	if err != nil || txId != "tx" {
+		t.Fatalf("unexpected result: %s %v", txId, err)
+	}
+	if reply["action"] != string(ActionAccept) || reply["countersignature"] != expected.Countersignature {
+		t.Fatalf("unexpected reply: %v", reply)
+	}
+
+	if _, err := client.AcceptTransferOffer(sender, "offer"); err != ErrInvalidAccount {
+		t.Fatalf("offer accepted by a non-receiver: %v", err)
+	}
+}
+
+func TestListTransferOffers(t *testing.T) {
+	acct, _ := AccountFromSeed(testnetData.seed)
+
+	var query url.Values
+	client, ts := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		writeJSON(w, map[string]interface{}{
+			"offers": []*TransferOffer{{Id: "offer", BitmarkId: testBitmarkId, Status: OfferStatusOpen}},
+		})
+	}), nil)
+	defer ts.Close()
+
+	offers, err := client.ListTransferOffers(acct, OfferIncoming, OfferStatusOpen)
+	if err != nil || len(offers) != 1 || offers[0].Id != "offer" {
+		t.Fatalf("unexpected result: %v %v", offers, err)
+	}
+	if query.Get("to") != acct.AccountNumber() || query.Get("from") != "" || query.Get("status") != "open" {
+		t.Errorf("unexpected incoming query: %v", query)
+	}
+
+	if _, err := client.ListTransferOffers(acct, OfferOutgoing, ""); err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("from") != acct.AccountNumber() || query.Get("to") != "" || query.Get("status") != "" {
+		t.Errorf("unexpected outgoing query: %v", query)
+	}
+
+	query = nil
+	for _, direction := range []TransferOfferDirection{"", "sideways"} {
+		if _, err := client.ListTransferOffers(acct, direction, ""); !errors.Is(err, ErrOfferDirection) {
+			t.Errorf("direction %q: unexpected error: %v", direction, err)
+		}
+	}
+	if query != nil {
+		t.Error("offers of an unknown direction requested")
+	}
+}
+
+func TestCloseTransferOffer(t *testing.T) {
+	acct, _ := AccountFromSeed(testnetData.seed)
+
+	var body struct {
+		Id    string            `json:"id"`
+		Reply map[string]string `json:"reply"`
+	}
+	client, ts := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" || r.Header.Get("requester") != acct.AccountNumber() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		writeJSON(w, map[string]string{})
+	}), nil)
+	defer ts.Close()
+
+	if err := client.RejectTransferOffer(acct, "offer"); err != nil {
+		t.Fatal(err)
+	}
+	if body.Id != "offer" || body.Reply["action"] != string(ActionReject) || body.Reply["countersignature"] != "" {
+		t.Errorf("unexpected reject request: %+v", body)
+	}
+
+	if err := client.CancelTransferOffer(acct, "offer"); err != nil {
+		t.Fatal(err)
+	}
+	if body.Id != "offer" || body.Reply["action"] != string(ActionCancel) {
+		t.Errorf("unexpected cancel request: %+v", body)
+	}
+}
+
+func bitmarkHandler(bmk *Bitmark) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"bitmark": bmk})
+	})
+}
+
+func TestInspectTransferOffer(t *testing.T) {
+	sender, _ := AccountFromSeed(testnetData.seed)
+	receiver, _ := AccountFromSeed("5XEECscX3EQvpqMH59Es92uE9KXuuFRQ5pmZsQtyJFiqLEEi7CqSpCo")
+	staleHead := "8f6d2e3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+
+	bmk := &Bitmark{Id: testBitmarkId, HeadId: testBitmarkId, Owner: sender.AccountNumber(), Status: "confirmed"}
+	var pending string
+	client, ts := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pending = r.URL.Query().Get("pending")
+		bitmarkHandler(bmk).ServeHTTP(w, r)
+	}), nil)
+	defer ts.Close()
+
+	valid, _ := NewTransferOffer(&Bitmark{Id: testBitmarkId, HeadId: testBitmarkId, Owner: sender.AccountNumber()}, testBitmarkId, receiver.AccountNumber(), sender)
+	forged, _ := NewTransferOffer(&Bitmark{Id: testBitmarkId, HeadId: testBitmarkId, Owner: sender.AccountNumber()}, testBitmarkId, receiver.AccountNumber(), receiver)
+	stale, _ := NewTransferOffer(&Bitmark{Id: testBitmarkId, HeadId: staleHead, Owner: sender.AccountNumber()}, staleHead, receiver.AccountNumber(), sender)
+	mismatched, _ := NewTransferOffer(&Bitmark{Id: testBitmarkId, HeadId: staleHead, Owner: sender.AccountNumber()}, testBitmarkId, receiver.AccountNumber(), sender)
+
+	cases := []struct {
+		offer    *TransferOffer
+		expected error
+	}{
+		{&TransferOffer{Record: *valid}, nil},
+		{&TransferOffer{Record: *forged}, ErrInvalidSignature},
+		{&TransferOffer{Record: *stale}, ErrOfferStaleLink},
+		{&TransferOffer{Record: *mismatched}, ErrOfferBitmarkMismatch},
+		{&TransferOffer{From: receiver.AccountNumber(), Record: *valid}, ErrOfferSenderNotOwner},
+		{&TransferOffer{Record: TransferOfferRecord{Link: testBitmarkId}}, ErrOfferBitmarkUnknown},
+	}
+	for i, c := range cases {
+		if err := client.InspectTransferOffer(c.offer); err != c.expected {
+			t.Errorf("case %d: expected %v, got %v", i, c.expected, err)
+		}
+	}
+
+	bmk.Status = "pending"
+	if err := client.InspectTransferOffer(&TransferOffer{Record: *valid}); err != ErrOfferBitmarkPending {
+		t.Errorf("pending bitmark not reported: %v", err)
+	}
+	if pending != "true" {
+		t.Errorf("bitmark inspected without the pending transactions: pending=%q", pending)
+	}
+
+	bmk.Status = "confirmed"
+	if _, err := client.CountersignTransfer(receiver, forged); err != ErrInvalidSignature {
+		t.Errorf("forged offer countersigned: %v", err)
+	}
+}
+
+func TestNetworkMismatch(t *testing.T) {
+	client := NewClient(&Config{Network: "testnet"})
+	testnetAcct, _ := AccountFromSeed(testnetData.seed)
+	livenetAcct, _ := AccountFromSeed(livenetData.seed)
+
+	errs := []error{}
+	_, err := client.Transfer(livenetAcct, testBitmarkId, testnetAcct.AccountNumber())
+	errs = append(errs, err)
+	_, err = client.Transfer(testnetAcct, testBitmarkId, livenetAcct.AccountNumber())
+	errs = append(errs, err)
+	_, err = client.SignTransferOffer(testnetAcct, testBitmarkId, livenetAcct.AccountNumber(), false)
+	errs = append(errs, err)
+	errs = append(errs, client.RentBitmark(testnetAcct, testBitmarkId, livenetAcct.AccountNumber(), 1))
+	_, err = client.IssueByAssetId(livenetAcct, AssetIdFromFingerprint(validFingerprint), 1)
+	errs = append(errs, err)
+	_, err = client.RestoreAccountFromSeed(livenetData.seed)
+	errs = append(errs, err)
+	_, err = client.RestoreAccountFromRecoveryPhrase(livenetData.phrase)
+	errs = append(errs, err)
+
+	for i, err := range errs {
+		if !errors.Is(err, ErrNetworkMismatch) {
+			t.Errorf("case %d: network mismatch not reported: %v", i, err)
+		}
+	}
+}
+
+func TestRestoreAccountFromRecoveryPhrase(t *testing.T) {
+	client := NewClient(&Config{Network: "testnet"})
+	acct, err := client.RestoreAccountFromRecoveryPhrase(testnetData.phrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acct.Seed() != testnetData.seed {
+		t.Fatalf("unexpected seed: %s", acct.Seed())
+	}
+
+	if _, err := client.AccountFromCore(acct.Core()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.AccountFromCore([]byte{0x01}); err != ErrSeedSizeMismatch {
+		t.Fatalf("invalid core accepted: %v", err)
+	}
+}
+
+func TestSessionNetworkMismatch(t *testing.T) {
+	sess := NewNetworkSession(nil, Testnet)
+	if _, err := sess.RestoreAccountFromSeed(livenetData.seed); !errors.Is(err, ErrNetworkMismatch) {
+		t.Errorf("network mismatch not reported: %v", err)
+	}
+	if _, err := sess.RestoreAccountFromSeed(testnetData.seed); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := AccountFromCore(Testnet, []byte{0x01}); err != ErrSeedSizeMismatch {
+		t.Errorf("invalid core accepted: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+)
+
+type accountResult struct {
+	AccountNumber  string   `json:"account_number"`
+	Network        string   `json:"network"`
+	Seed           string   `json:"seed,omitempty"`
+	RecoveryPhrase []string `json:"recovery_phrase,omitempty"`
+	Keystore       string   `json:"keystore,omitempty"`
+}
+
+func (r accountResult) print(w io.Writer) {
+	fmt.Fprintf(w, "account number:\t%s\n", r.AccountNumber)
+	fmt.Fprintf(w, "network:\t%s\n", r.Network)
+	if r.Seed != "" {
+		fmt.Fprintf(w, "seed:\t%s\n", r.Seed)
+	}
+	if len(r.RecoveryPhrase) > 0 {
+		fmt.Fprintf(w, "recovery phrase:\t%s\n", strings.Join(r.RecoveryPhrase, " "))
+	}
+	if r.Keystore != "" {
+		fmt.Fprintf(w, "keystore:\t%s\n", r.Keystore)
+	}
+}
+
+// saveAccount writes the account to the keystore file, encrypted by the passphrase
+func saveAccount(acct *sdk.Account, path string) error {
+	passphrase, err := readPassphrase()
+	if err != nil {
+		return err
+	}
+	if passphrase == "" {
+		return usageError("empty passphrase")
+	}
+
+	ks, err := encryptKeystore(acct, passphrase)
+	if err != nil {
+		return err
+	}
+	return writeKeystore(path, ks)
+}
+
+// runAccountCreate creates an account. The recovery phrase is printed once for an offline backup.
+func runAccountCreate(fs *flag.FlagSet, args []string) error {
+	out := fs.String("out", "", "save the account to the keystore `file`")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	acct, err := client.CreateAccount()
+	if err != nil {
+		return err
+	}
+
+	result := accountResult{AccountNumber: acct.AccountNumber(), Network: opts.network, RecoveryPhrase: acct.RecoveryPhrase()}
+	if *out != "" {
+		if err := saveAccount(acct, *out); err != nil {
+			return err
+		}
+		result.Keystore = *out
+	} else {
+		result.Seed = acct.Seed()
+	}
+
+	output(result, result.print)
+	return nil
+}
+
+func runAccountRestore(fs *flag.FlagSet, args []string) error {
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	acct, err := readAccountSecret()
+	if err != nil {
+		return err
+	}
+
+	result := accountResult{AccountNumber: acct.AccountNumber(), Network: opts.network}
+	output(result, result.print)
+	return nil
+}
+
+func runAccountInfo(fs *flag.FlagSet, args []string) error {
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if opts.keystore == "" {
+		return usageError("--keystore not set")
+	}
+
+	ks, err := readKeystore(opts.keystore)
+	if err != nil {
+		return err
+	}
+
+	result := accountResult{AccountNumber: ks.AccountNumber, Network: opts.network, Keystore: opts.keystore}
+	output(result, result.print)
+	return nil
+}
+
+func runKeystoreImport(fs *flag.FlagSet, args []string) error {
+	out := fs.String("out", "", "keystore `file` to create")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "out"); err != nil {
+		return err
+	}
+
+	acct, err := readAccountSecret()
+	if err != nil {
+		return err
+	}
+
+	if err := saveAccount(acct, *out); err != nil {
+		return err
+	}
+
+	result := accountResult{AccountNumber: acct.AccountNumber(), Network: opts.network, Keystore: *out}
+	output(result, result.print)
+	return nil
+}
+
+func runKeystoreExport(fs *flag.FlagSet, args []string) error {
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if opts.keystore == "" {
+		return usageError("--keystore not set")
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+
+	result := accountResult{
+		AccountNumber:  acct.AccountNumber(),
+		Network:        opts.network,
+		Seed:           acct.Seed(),
+		RecoveryPhrase: acct.RecoveryPhrase(),
+	}
+	output(result, result.print)
+	return nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+)
+
+type assetResult struct {
+	AssetId     string   `json:"asset_id"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	BitmarkIds  []string `json:"bitmark_ids,omitempty"`
+}
+
+func (r assetResult) print(w io.Writer) {
+	fmt.Fprintf(w, "asset id:\t%s\n", r.AssetId)
+	if r.Fingerprint != "" {
+		fmt.Fprintf(w, "fingerprint:\t%s\n", r.Fingerprint)
+	}
+	for _, id := range r.BitmarkIds {
+		fmt.Fprintf(w, "bitmark id:\t%s\n", id)
+	}
+}
+
+// fileFingerprint computes the fingerprint of the file
+func fileFingerprint(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return sdk.ComputeFingerprint(f)
+}
+
+// assetFlags are the properties of an asset to register
+type assetFlags struct {
+	file        *string
+	fingerprint *string
+	name        *string
+	metadata    metadataFlag
+}
+
+func addAssetFlags(fs *flag.FlagSet) *assetFlags {
+	a := &assetFlags{
+		file:        fs.String("file", "", "asset `file`"),
+		fingerprint: fs.String("fingerprint", "", "fingerprint of the asset, instead of --file"),
+		name:        fs.String("name", "", "asset name"),
+		metadata:    make(metadataFlag),
+	}
+	fs.Var(a.metadata, "meta", "asset metadata as `key=value`, repeatable")
+	return a
+}
+
+// info returns nil if neither name nor metadata is set, for an asset already registered
+func (a *assetFlags) info() *sdk.AssetInfo {
+	if *a.name == "" && len(a.metadata) == 0 {
+		return nil
+	}
+	return &sdk.AssetInfo{Name: *a.name, Metadata: a.metadata}
+}
+
+func (a *assetFlags) resolveFingerprint() (string, error) {
+	switch {
+	case *a.file != "" && *a.fingerprint != "":
+		return "", usageError("--file and --fingerprint are exclusive")
+	case *a.file != "":
+		return fileFingerprint(*a.file)
+	case *a.fingerprint != "":
+		return *a.fingerprint, nil
+	}
+	return "", usageError("--file or --fingerprint not set")
+}
+
+func runAssetRegister(fs *flag.FlagSet, args []string) error {
+	asset := addAssetFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	fingerprint, err := asset.resolveFingerprint()
+	if err != nil {
+		return err
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+
+	assetId, err := client.RegisterAsset(acct, fingerprint, &sdk.AssetInfo{Name: *asset.name, Metadata: asset.metadata})
+	if err != nil {
+		return err
+	}
+
+	result := assetResult{AssetId: assetId, Fingerprint: fingerprint}
+	output(result, result.print)
+	return nil
+}
+
+func runAssetQuery(fs *flag.FlagSet, args []string) error {
+	id := fs.String("id", "", "asset id")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "id"); err != nil {
+		return err
+	}
+
+	asset, err := client.GetAsset(*id)
+	if err != nil {
+		return err
+	}
+
+	output(asset, func(w io.Writer) {
+		fmt.Fprintf(w, "asset id:\t%s\n", asset.Id)
+		fmt.Fprintf(w, "name:\t%s\n", asset.Name)
+		fmt.Fprintf(w, "fingerprint:\t%s\n", asset.Fingerprint)
+		fmt.Fprintf(w, "registrant:\t%s\n", asset.Registrant)
+		fmt.Fprintf(w, "status:\t%s\n", asset.Status)
+		keys := make([]string, 0, len(asset.Metadata))
+		for k := range asset.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "metadata %s:\t%s\n", k, asset.Metadata[k])
+		}
+	})
+	return nil
+}
+
+func runAssetFingerprint(fs *flag.FlagSet, args []string) error {
+	file := fs.String("file", "", "asset `file`")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "file"); err != nil {
+		return err
+	}
+
+	fingerprint, err := fileFingerprint(*file)
+	if err != nil {
+		return err
+	}
+
+	result := assetResult{AssetId: sdk.AssetIdFromFingerprint(fingerprint), Fingerprint: fingerprint}
+	output(result, result.print)
+	return nil
+}
+
+func printBitmarks(bitmarks []*sdk.Bitmark) func(w io.Writer) {
+	return func(w io.Writer) {
+		fmt.Fprintln(w, "BITMARK ID\tASSET ID\tOWNER\tSTATUS")
+		for _, b := range bitmarks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", b.Id, b.AssetId, b.Owner, b.Status)
+		}
+	}
+}
+
+func runBitmarkQuery(fs *flag.FlagSet, args []string) error {
+	var filter sdk.BitmarkFilter
+	fs.StringVar(&filter.AssetId, "asset-id", "", "bitmarks of the asset")
+	fs.StringVar(&filter.Issuer, "issuer", "", "bitmarks issued by the account")
+	fs.StringVar(&filter.Owner, "owner", "", "bitmarks owned by the account")
+	fs.BoolVar(&filter.OwnerSent, "owner-sent", false, "include the bitmarks sent by --owner")
+	fs.BoolVar(&filter.Pending, "pending", false, "include the pending bitmarks")
+	fs.UintVar(&filter.Limit, "limit", 100, "maximum number of bitmarks")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	bitmarks, err := client.QueryBitmarks(&filter)
+	if err != nil {
+		return err
+	}
+
+	output(bitmarks, printBitmarks(bitmarks))
+	return nil
+}
+
+func runBitmarkGet(fs *flag.FlagSet, args []string) error {
+	id := fs.String("id", "", "bitmark id")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "id"); err != nil {
+		return err
+	}
+
+	bitmark, err := client.GetBitmark(*id)
+	if err != nil {
+		return err
+	}
+
+	output(bitmark, printBitmarks([]*sdk.Bitmark{bitmark}))
+	return nil
+}
+
+func runIssue(fs *flag.FlagSet, args []string) error {
+	asset := addAssetFlags(fs)
+	assetId := fs.String("asset-id", "", "issue a registered asset, instead of --file or --fingerprint")
+	accessibility := fs.String("accessibility", string(sdk.Public), "accessibility of the --file: public or private")
+	quantity := fs.Int("quantity", 1, "number of bitmarks")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *quantity < 1 {
+		return usageError("--quantity must be positive")
+	}
+
+	acs := sdk.Accessibility(*accessibility)
+	if acs != sdk.Public && acs != sdk.Private {
+		return usageError(fmt.Sprintf("unknown accessibility: %s", *accessibility))
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+
+	var result assetResult
+	switch {
+	case *assetId != "":
+		if *asset.file != "" || *asset.fingerprint != "" {
+			return usageError("--asset-id is exclusive with --file and --fingerprint")
+		}
+		result.AssetId = *assetId
+		result.BitmarkIds, err = client.IssueByAssetId(acct, *assetId, *quantity)
+	case *asset.file != "":
+		var af *sdk.AssetFile
+		af, err = sdk.NewAssetFileFromPath(*asset.file, acs)
+		if err != nil {
+			return err
+		}
+		result.AssetId, result.Fingerprint = af.Id(), af.Fingerprint
+		result.BitmarkIds, err = client.IssueByAssetFile(acct, af, *quantity, asset.info())
+	default:
+		result.Fingerprint, err = asset.resolveFingerprint()
+		if err != nil {
+			return err
+		}
+		result.AssetId = sdk.AssetIdFromFingerprint(result.Fingerprint)
+		result.BitmarkIds, err = client.IssueByFingerprint(acct, result.Fingerprint, *quantity, asset.info())
+	}
+	if err != nil {
+		return err
+	}
+
+	output(result, result.print)
+	return nil
+}
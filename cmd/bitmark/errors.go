@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+)
+
+// exit codes by the kind of the error
+const (
+	exitOK            = 0
+	exitInternal      = 1
+	exitUsage         = 2
+	exitInvalid       = 3
+	exitService       = 4
+	exitIntegrity     = 5
+	exitLeaseExpired  = 6
+	exitOfferRejected = 7
+	exitKeystore      = 8
+)
+
+var errorKinds = map[int]string{
+	exitInternal:      "internal",
+	exitUsage:         "usage",
+	exitInvalid:       "invalid_input",
+	exitService:       "service",
+	exitIntegrity:     "integrity",
+	exitLeaseExpired:  "lease_expired",
+	exitOfferRejected: "offer_rejected",
+	exitKeystore:      "keystore",
+}
+
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// fail reports the error on stderr, in JSON with --json, and returns the exit code of its kind
+func fail(err error) int {
+	code := exitCode(err)
+
+	if opts.json {
+		body := map[string]interface{}{"kind": errorKinds[code], "message": err.Error()}
+		var se *sdk.ServiceError
+		if errors.As(err, &se) {
+			body["code"] = se.Code
+		}
+		json.NewEncoder(os.Stderr).Encode(map[string]interface{}{"error": body})
+	} else {
+		fmt.Fprintf(os.Stderr, "bitmark: %s\n", err)
+	}
+	return code
+}
+
+func exitCode(err error) int {
+	var ue usageError
+	var se *sdk.ServiceError
+	var pe sdk.AssetPropertyErrors
+	var ie *sdk.IntegrityError
+	var le *sdk.LeaseExpiredError
+	var lnf *sdk.LeaseNotFoundError
+	var be sdk.BulkLeaseError
+
+	switch {
+	case errors.As(err, &ue):
+		return exitUsage
+	case errors.As(err, &se):
+		return exitService
+	case errors.As(err, &pe):
+		return exitInvalid
+	case errors.As(err, &ie):
+		return exitIntegrity
+	case errors.As(err, &le):
+		return exitLeaseExpired
+	case errors.As(err, &lnf):
+		return exitInvalid
+	case errors.Is(err, errKeystorePassphrase), errors.Is(err, errKeystoreFormat):
+		return exitKeystore
+	case errors.As(err, &be) && len(be) > 0:
+		// the kind of the first failure by renter
+		renters := make([]string, 0, len(be))
+		for r := range be {
+			renters = append(renters, r)
+		}
+		sort.Strings(renters)
+		return exitCode(be[renters[0]])
+	}
+
+	for _, target := range []error{
+		sdk.ErrOfferBitmarkUnknown,
+		sdk.ErrOfferSenderNotOwner,
+		sdk.ErrOfferStaleLink,
+		sdk.ErrOfferBitmarkMismatch,
+		sdk.ErrOfferBitmarkPending,
+		sdk.ErrInvalidSignature,
+	} {
+		if errors.Is(err, target) {
+			return exitOfferRejected
+		}
+	}
+
+	for _, target := range []error{
+		sdk.ErrNetworkMismatch,
+		sdk.ErrAccountNumberSizeMismatch,
+		sdk.ErrAccountNumberChecksumMismatch,
+		sdk.ErrAccountNumberUnknownAlgorithm,
+		sdk.ErrSeedSizeMismatch,
+		sdk.ErrSeedHeaderMismatch,
+		sdk.ErrSeedChecksumMismatch,
+		sdk.ErrInvalidLength,
+		sdk.ErrInvalidAccount,
+		sdk.ErrUnknownFingerprintScheme,
+	} {
+		if errors.Is(err, target) {
+			return exitInvalid
+		}
+	}
+	return exitInternal
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keystoreVersion = 1
+	keystoreKDF     = "scrypt"
+	keystoreCipher  = "xsalsa20-poly1305"
+
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	errKeystorePassphrase = errors.New("wrong keystore passphrase")
+	errKeystoreFormat     = errors.New("unsupported keystore format")
+)
+
+// keystore is the file holding the seed of an account encrypted by a passphrase
+type keystore struct {
+	Version       int            `json:"version"`
+	AccountNumber string         `json:"account_number"`
+	Crypto        keystoreCrypto `json:"crypto"`
+}
+
+type keystoreCrypto struct {
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       string `json:"salt"`
+	Cipher     string `json:"cipher"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+func encryptKeystore(acct *sdk.Account, passphrase string) (*keystore, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+
+	key, err := keystoreKey(passphrase, salt, scryptN, scryptR, scryptP)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keystore{
+		Version:       keystoreVersion,
+		AccountNumber: acct.AccountNumber(),
+		Crypto: keystoreCrypto{
+			KDF:        keystoreKDF,
+			N:          scryptN,
+			R:          scryptR,
+			P:          scryptP,
+			Salt:       hex.EncodeToString(salt),
+			Cipher:     keystoreCipher,
+			Nonce:      hex.EncodeToString(nonce[:]),
+			Ciphertext: hex.EncodeToString(secretbox.Seal(nil, []byte(acct.Seed()), &nonce, key)),
+		},
+	}, nil
+}
+
+// decrypt returns the seed of the keystore
+func (ks *keystore) decrypt(passphrase string) (string, error) {
+	c := ks.Crypto
+	if ks.Version != keystoreVersion || c.KDF != keystoreKDF || c.Cipher != keystoreCipher {
+		return "", errKeystoreFormat
+	}
+
+	salt, err := hex.DecodeString(c.Salt)
+	if err != nil {
+		return "", errKeystoreFormat
+	}
+	nonce, err := hex.DecodeString(c.Nonce)
+	if err != nil || len(nonce) != 24 {
+		return "", errKeystoreFormat
+	}
+	ciphertext, err := hex.DecodeString(c.Ciphertext)
+	if err != nil {
+		return "", errKeystoreFormat
+	}
+
+	key, err := keystoreKey(passphrase, salt, c.N, c.R, c.P)
+	if err != nil {
+		return "", errKeystoreFormat
+	}
+
+	var n [24]byte
+	copy(n[:], nonce)
+	seed, ok := secretbox.Open(nil, ciphertext, &n, key)
+	if !ok {
+		return "", errKeystorePassphrase
+	}
+	return string(seed), nil
+}
+
+func keystoreKey(passphrase string, salt []byte, n, r, p int) (*[32]byte, error) {
+	derived, err := scrypt.Key([]byte(passphrase), salt, n, r, p, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	var key [32]byte
+	copy(key[:], derived)
+	return &key, nil
+}
+
+func readKeystore(path string) (*keystore, error) {
+	dat, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ks keystore
+	if err := json.Unmarshal(dat, &ks); err != nil {
+		return nil, errKeystoreFormat
+	}
+	return &ks, nil
+}
+
+// writeKeystore saves the keystore, refusing to overwrite an existing file
+func writeKeystore(path string, ks *keystore) error {
+	dat, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(dat, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// readSecret reads a line from stdin, prompting on stderr if stdin is a terminal
+func readSecret(prompt string) (string, error) {
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	}
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("unable to read the %s from stdin: %s", strings.ToLower(prompt), err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func readPassphrase() (string, error) {
+	if p := os.Getenv("BITMARK_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	return readSecret("Passphrase")
+}
+
+// readAccountSecret restores the account from the seed or the recovery phrase on stdin
+func readAccountSecret() (*sdk.Account, error) {
+	secret, err := readSecret("Seed or recovery phrase")
+	if err != nil {
+		return nil, err
+	}
+
+	if words := strings.Fields(secret); len(words) > 1 {
+		return client.RestoreAccountFromRecoveryPhrase(strings.Join(words, " "))
+	}
+	return client.RestoreAccountFromSeed(secret)
+}
+
+// loadAccount restores the account of the command from the keystore, or from stdin without one
+func loadAccount() (*sdk.Account, error) {
+	if opts.keystore == "" {
+		return readAccountSecret()
+	}
+
+	ks, err := readKeystore(opts.keystore)
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, err := readPassphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := ks.decrypt(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return client.RestoreAccountFromSeed(seed)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+)
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	acct, err := sdk.AccountFromSeed("5XEECttxvRBzxzAmuV4oh6T1FcQu4mBg8eWd9wKbf8hweXsfwtJ8sfH")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := encryptKeystore(acct, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "account.json")
+	if err := writeKeystore(path, ks); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeKeystore(path, ks); err == nil {
+		t.Fatal("existing keystore overwritten")
+	}
+
+	ks, err = readKeystore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ks.AccountNumber != acct.AccountNumber() {
+		t.Fatalf("unexpected account number: %s", ks.AccountNumber)
+	}
+
+	seed, err := ks.decrypt("passphrase")
+	if err != nil || seed != acct.Seed() {
+		t.Fatalf("unexpected seed: %s %v", seed, err)
+	}
+
+	if _, err := ks.decrypt("wrong"); err != errKeystorePassphrase {
+		t.Fatalf("wrong passphrase not detected: %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+)
+
+func runLeaseCreate(fs *flag.FlagSet, args []string) error {
+	bitmarkId := fs.String("bitmark-id", "", "bitmark of the private asset")
+	var renters listFlag
+	fs.Var(&renters, "renter", "account number of a renter, repeatable")
+	days := fs.Uint("days", 0, "duration of the lease in days")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "bitmark-id", "renter", "days"); err != nil {
+		return err
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+
+	err = client.RentBitmarkToMany(acct, *bitmarkId, renters, *days)
+	if errs, ok := err.(sdk.BulkLeaseError); ok {
+		failed := make(map[string]string)
+		for renter, e := range errs {
+			failed[renter] = e.Error()
+		}
+		output(map[string]interface{}{"failed": failed}, func(w io.Writer) {
+			for renter, msg := range failed {
+				fmt.Fprintf(w, "%s:\t%s\n", renter, msg)
+			}
+		})
+	}
+	return err
+}
+
+func runLeaseRenew(fs *flag.FlagSet, args []string) error {
+	bitmarkId := fs.String("bitmark-id", "", "bitmark of the private asset")
+	renter := fs.String("renter", "", "account number of the renter")
+	days := fs.Uint("days", 0, "duration of the lease in days")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "bitmark-id", "renter", "days"); err != nil {
+		return err
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+	return client.RenewLease(acct, *bitmarkId, *renter, *days)
+}
+
+func runLeaseRevoke(fs *flag.FlagSet, args []string) error {
+	bitmarkId := fs.String("bitmark-id", "", "bitmark of the private asset")
+	renter := fs.String("renter", "", "account number of the renter")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "bitmark-id", "renter"); err != nil {
+		return err
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+	return client.RevokeLease(acct, *bitmarkId, *renter)
+}
+
+func printLeases(leases []*sdk.Lease) func(w io.Writer) {
+	return func(w io.Writer) {
+		fmt.Fprintln(w, "BITMARK ID\tOWNER\tRENTER\tEXPIRES AT")
+		for _, l := range leases {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", l.BitmarkId, l.Owner, l.Renter, l.ExpiresAt.Format(time.RFC3339))
+		}
+	}
+}
+
+func runLeaseList(fs *flag.FlagSet, args []string) error {
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+
+	leases, err := client.ListLeases(acct)
+	if err != nil {
+		return err
+	}
+
+	output(leases, printLeases(leases))
+	return nil
+}
+
+func runLeaseGranted(fs *flag.FlagSet, args []string) error {
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+
+	leases, err := client.ListGrantedLeases(acct)
+	if err != nil {
+		return err
+	}
+
+	output(leases, printLeases(leases))
+	return nil
+}
+
+type downloadResult struct {
+	File string `json:"file"`
+	Size int    `json:"size"`
+}
+
+// runDownload saves the asset of an owned bitmark, or of a leased one with --lease.
+// The content is verified against the asset fingerprint by the SDK.
+func runDownload(fs *flag.FlagSet, args []string) error {
+	bitmarkId := fs.String("bitmark-id", "", "bitmark of the asset")
+	out := fs.String("out", "", "output `file`, or the directory for the original file name")
+	byLease := fs.Bool("lease", false, "download by the lease granted to the account")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "bitmark-id"); err != nil {
+		return err
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+
+	var fileName string
+	var content []byte
+	if *byLease {
+		content, err = downloadByLease(acct, *bitmarkId)
+		fileName = *bitmarkId
+	} else {
+		fileName, content, err = client.DownloadAsset(acct, *bitmarkId)
+	}
+	if err != nil {
+		return err
+	}
+
+	path := *out
+	if fi, err := os.Stat(path); path == "" || err == nil && fi.IsDir() {
+		path = filepath.Join(path, filepath.Base(fileName))
+	}
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		return err
+	}
+
+	result := downloadResult{path, len(content)}
+	output(result, func(w io.Writer) {
+		fmt.Fprintf(w, "file:\t%s\n", result.File)
+		fmt.Fprintf(w, "size:\t%d\n", result.Size)
+	})
+	return nil
+}
+
+func downloadByLease(acct *sdk.Account, bitmarkId string) ([]byte, error) {
+	leases, err := client.ListLeases(acct)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range leases {
+		if l.BitmarkId == bitmarkId {
+			return client.DownloadAssetByLease(acct, l)
+		}
+	}
+	return nil, errors.New("no lease of the bitmark granted to the account")
+}
+
+type verifyResult struct {
+	Fingerprint string `json:"fingerprint"`
+	Verified    bool   `json:"verified"`
+}
+
+// runVerify checks that the file hashes to the fingerprint, given or of the asset of the bitmark
+func runVerify(fs *flag.FlagSet, args []string) error {
+	file := fs.String("file", "", "`file` to verify")
+	fingerprint := fs.String("fingerprint", "", "expected fingerprint")
+	bitmarkId := fs.String("bitmark-id", "", "verify against the asset of the bitmark, instead of --fingerprint")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "file"); err != nil {
+		return err
+	}
+
+	expected := *fingerprint
+	switch {
+	case *bitmarkId != "" && expected != "":
+		return usageError("--fingerprint and --bitmark-id are exclusive")
+	case *bitmarkId != "":
+		bitmark, err := client.GetBitmark(*bitmarkId)
+		if err != nil {
+			return err
+		}
+		expected = bitmark.Asset.Fingerprint
+	case expected == "":
+		return usageError("--fingerprint or --bitmark-id not set")
+	}
+
+	content, err := ioutil.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	if err := sdk.VerifyFingerprint(expected, content); err != nil {
+		return err
+	}
+
+	result := verifyResult{expected, true}
+	output(result, func(w io.Writer) {
+		fmt.Fprintf(w, "verified:\t%s\n", result.Fingerprint)
+	})
+	return nil
+}
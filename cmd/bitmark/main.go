@@ -0,0 +1,296 @@
+// Command bitmark manages Bitmark accounts, assets, bitmarks, offers and leases.
+//
+// Usage:
+//
+//	bitmark [global flags] <command> [<subcommand>] [flags]
+//
+// Account secrets are read from an encrypted keystore file (--keystore) or,
+// without one, as a seed or recovery phrase from stdin. They are never
+// accepted as arguments. The keystore passphrase is read from
+// BITMARK_PASSPHRASE or stdin.
+//
+// The client is configured like the other Bitmark tools, by the file of --config
+// or BITMARK_CONFIG and the BITMARK_* variables of the SDK settings, e.g.
+// BITMARK_NETWORK, BITMARK_API_ENDPOINT, BITMARK_KEY_ENDPOINT or BITMARK_KEYSTORE.
+// The global flags override them.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+)
+
+type options struct {
+	config      string
+	network     string
+	endpoint    string
+	keyEndpoint string
+	keystore    string
+	timeout     time.Duration
+	json        bool
+}
+
+// defaultTimeout of the requests, longer than the one of the SDK for the uploads
+const defaultTimeout = 30
+
+var (
+	opts   options
+	client *sdk.Client
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(fs *flag.FlagSet, args []string) error
+}
+
+func commands() []command {
+	return []command{
+		{"account create", "create an account, optionally saved to a keystore with --out", runAccountCreate},
+		{"account restore", "restore an account from the seed or recovery phrase on stdin", runAccountRestore},
+		{"account info", "show the account of the keystore", runAccountInfo},
+		{"keystore import", "save the seed or recovery phrase on stdin to a keystore", runKeystoreImport},
+		{"keystore export", "print the seed and recovery phrase of the keystore", runKeystoreExport},
+
+		{"asset register", "register an asset by a file or a fingerprint", runAssetRegister},
+		{"asset query", "get a registered asset", runAssetQuery},
+		{"asset fingerprint", "compute the fingerprint and asset id of a file", runAssetFingerprint},
+		{"bitmark query", "query bitmarks", runBitmarkQuery},
+		{"bitmark get", "get a bitmark", runBitmarkGet},
+
+		{"issue", "issue bitmarks of a file, a fingerprint or a registered asset", runIssue},
+		{"transfer", "transfer a bitmark with the owner signature only", runTransfer},
+
+		{"offer create", "sign a transfer offer and optionally submit it", runOfferCreate},
+		{"offer list", "list the transfer offers of the account", runOfferList},
+		{"offer accept", "countersign and accept a transfer offer", runOfferAccept},
+		{"offer reject", "reject a transfer offer", runOfferReject},
+		{"offer cancel", "cancel a transfer offer", runOfferCancel},
+		{"offer countersign", "countersign a transfer offer record read from a file", runOfferCountersign},
+
+		{"lease create", "lease a private asset to renters", runLeaseCreate},
+		{"lease renew", "renew a lease", runLeaseRenew},
+		{"lease revoke", "revoke a lease", runLeaseRevoke},
+		{"lease list", "list the leases granted to the account", runLeaseList},
+		{"lease granted", "list the leases granted by the account", runLeaseGranted},
+
+		{"download", "download the asset of a bitmark", runDownload},
+		{"verify", "verify a file against a fingerprint or the asset of a bitmark", runVerify},
+	}
+}
+
+func addGlobalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&opts.config, "config", os.Getenv("BITMARK_CONFIG"), "configuration `file` of the client")
+	fs.StringVar(&opts.network, "network", "", "network: livenet, testnet or a registered one (default from the configuration, or livenet)")
+	fs.StringVar(&opts.endpoint, "endpoint", "", "override the API endpoint")
+	fs.StringVar(&opts.keyEndpoint, "key-endpoint", "", "override the key endpoint")
+	fs.StringVar(&opts.keystore, "keystore", "", "keystore `file` of the account (default from the configuration)")
+	fs.DurationVar(&opts.timeout, "timeout", defaultTimeout*time.Second, "timeout of the requests")
+	fs.BoolVar(&opts.json, "json", false, "print the results in JSON")
+}
+
+// loadSettings loads the settings of the configuration file and the environment,
+// overridden by the global flags set
+func loadSettings(fs *flag.FlagSet) (*sdk.Settings, error) {
+	settings := sdk.DefaultSettings()
+	settings.Timeout = defaultTimeout
+	if opts.config != "" {
+		if err := settings.LoadFile(opts.config); err != nil {
+			return nil, err
+		}
+	}
+	if err := settings.LoadEnv(); err != nil {
+		return nil, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "network":
+			settings.Network = opts.network
+		case "endpoint":
+			settings.APIEndpoint = opts.endpoint
+		case "key-endpoint":
+			settings.KeyEndpoint = opts.keyEndpoint
+		case "keystore":
+			settings.Keystore = opts.keystore
+		}
+	})
+
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	global := flag.NewFlagSet("bitmark", flag.ContinueOnError)
+	global.Usage = func() { usage(global) }
+	addGlobalFlags(global)
+	if err := global.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	cmd, rest, ok := findCommand(global.Args())
+	if !ok {
+		usage(global)
+		return exitUsage
+	}
+
+	// the global flags are accepted after the command as well, so the
+	// ones already set are carried over to the flags of the command
+	set := make(map[string]string)
+	global.Visit(func(f *flag.Flag) { set[f.Name] = f.Value.String() })
+
+	fs := flag.NewFlagSet("bitmark "+cmd.name, flag.ContinueOnError)
+	addGlobalFlags(fs)
+	for name, value := range set {
+		fs.Set(name, value)
+	}
+
+	err := cmd.run(fs, rest)
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+func findCommand(args []string) (command, []string, bool) {
+	for _, n := range []int{2, 1} {
+		if len(args) < n {
+			continue
+		}
+		name := strings.Join(args[:n], " ")
+		for _, c := range commands() {
+			if c.name == name {
+				return c, args[n:], true
+			}
+		}
+	}
+	return command{}, nil, false
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintln(w, "Usage: bitmark [global flags] <command> [flags]")
+	fmt.Fprintln(w, "\nCommands:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, c := range commands() {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.usage)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "\nGlobal flags:")
+	fs.PrintDefaults()
+}
+
+// parseFlags parses the flags of the command, and sets up the client for the network
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if fs.NArg() > 0 {
+		return usageError(fmt.Sprintf("unexpected arguments: %s", strings.Join(fs.Args(), " ")))
+	}
+
+	settings, err := loadSettings(fs)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	opts.network = settings.Network
+	opts.keystore = settings.Keystore
+
+	cfg, err := settings.Config()
+	if err != nil {
+		return usageError(err.Error())
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "timeout" {
+			cfg.HTTPClient.Timeout = opts.timeout
+		}
+	})
+
+	profile, err := sdk.LookupNetwork(settings.Network)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	client, err = sdk.NewClientWithProfile(profile, cfg)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	return nil
+}
+
+// output prints the result as JSON with --json, or in the human readable form
+func output(v interface{}, human func(w io.Writer)) {
+	if opts.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(v)
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	human(tw)
+	tw.Flush()
+}
+
+// metadataFlag collects the repeated --meta key=value flags
+type metadataFlag map[string]string
+
+func (m metadataFlag) String() string {
+	parts := make([]string, 0, len(m))
+	for k, v := range m {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (m metadataFlag) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return fmt.Errorf("metadata %q is not in the form key=value", s)
+	}
+
+	key, val := s[:i], s[i+1:]
+	if _, ok := m[key]; ok {
+		return fmt.Errorf("duplicate metadata key %q", key)
+	}
+	m[key] = val
+	return nil
+}
+
+// listFlag collects a repeated flag
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func required(fs *flag.FlagSet, names ...string) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, n := range names {
+		if !set[n] {
+			return usageError(fmt.Sprintf("--%s not set", n))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+)
+
+func TestMetadataFlag(t *testing.T) {
+	m := make(metadataFlag)
+	if err := m.Set("url=https://example.com/a:b"); err != nil {
+		t.Fatal(err)
+	}
+	if m["url"] != "https://example.com/a:b" {
+		t.Fatalf("unexpected value: %s", m["url"])
+	}
+
+	if err := m.Set("url=again"); err == nil {
+		t.Error("duplicate key accepted")
+	}
+	if err := m.Set("=value"); err == nil {
+		t.Error("empty key accepted")
+	}
+	if err := m.Set("novalue"); err == nil {
+		t.Error("missing separator accepted")
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	cases := map[int]error{
+		exitUsage:         usageError("--id not set"),
+		exitService:       fmt.Errorf("wrapped: %w", &sdk.ServiceError{Code: 1000, Message: "invalid"}),
+		exitIntegrity:     &sdk.IntegrityError{},
+		exitOfferRejected: sdk.ErrOfferStaleLink,
+		exitInvalid:       sdk.ErrNetworkMismatch,
+		exitKeystore:      errKeystorePassphrase,
+		exitInternal:      errors.New("unexpected"),
+	}
+	for code, err := range cases {
+		if c := exitCode(err); c != code {
+			t.Errorf("%v: expected %d, got %d", err, code, c)
+		}
+	}
+}
+
+func TestFindCommand(t *testing.T) {
+	cmd, rest, ok := findCommand([]string{"offer", "accept", "--id", "1"})
+	if !ok || cmd.name != "offer accept" || len(rest) != 2 {
+		t.Fatalf("unexpected command: %s %v", cmd.name, rest)
+	}
+
+	cmd, _, ok = findCommand([]string{"issue", "--quantity", "2"})
+	if !ok || cmd.name != "issue" {
+		t.Fatalf("unexpected command: %s", cmd.name)
+	}
+
+	if _, _, ok := findCommand([]string{"offer"}); ok {
+		t.Fatal("incomplete command accepted")
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bitmark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := filepath.Join(dir, "bitmark.conf")
+	dat := "network = \"testnet\"\napi_endpoint = \"https://api.example.com\"\nkeystore = \"from-config.json\"\n"
+	if err := ioutil.WriteFile(config, []byte(dat), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("BITMARK_KEY_ENDPOINT", "https://key.example.com")
+	os.Setenv("BITMARK_KEYSTORE", "from-env.json")
+	defer os.Unsetenv("BITMARK_KEY_ENDPOINT")
+	defer os.Unsetenv("BITMARK_KEYSTORE")
+
+	opts = options{}
+	fs := flag.NewFlagSet("bitmark", flag.ContinueOnError)
+	addGlobalFlags(fs)
+	if err := fs.Parse([]string{"--config", config, "--endpoint", "https://api.example.org"}); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := loadSettings(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Network != "testnet" || settings.Timeout != defaultTimeout {
+		t.Errorf("unexpected settings: %s", settings)
+	}
+	if settings.APIEndpoint != "https://api.example.org" || settings.KeyEndpoint != "https://key.example.com" {
+		t.Errorf("unexpected endpoints: %s", settings)
+	}
+	if settings.Keystore != "from-env.json" {
+		t.Errorf("unexpected keystore: %s", settings.Keystore)
+	}
+
+	if err := fs.Parse([]string{"--network", "devnet"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSettings(fs); err == nil {
+		t.Error("unknown network accepted")
+	}
+}
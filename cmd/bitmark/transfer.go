@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+)
+
+type txResult struct {
+	TxId string `json:"tx_id,omitempty"`
+}
+
+func (r txResult) print(w io.Writer) {
+	if r.TxId != "" {
+		fmt.Fprintf(w, "tx id:\t%s\n", r.TxId)
+	}
+}
+
+func runTransfer(fs *flag.FlagSet, args []string) error {
+	bitmarkId := fs.String("bitmark-id", "", "bitmark to transfer")
+	receiver := fs.String("receiver", "", "account number of the receiver")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "bitmark-id", "receiver"); err != nil {
+		return err
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+
+	txId, err := client.Transfer(acct, *bitmarkId, *receiver)
+	if err != nil {
+		return err
+	}
+
+	result := txResult{txId}
+	output(result, result.print)
+	return nil
+}
+
+type offerResult struct {
+	OfferId string                   `json:"offer_id,omitempty"`
+	Record  *sdk.TransferOfferRecord `json:"record"`
+}
+
+// runOfferCreate signs a transfer offer. The record is printed for the receiver
+// to countersign out of band, or submitted to the API with --submit.
+func runOfferCreate(fs *flag.FlagSet, args []string) error {
+	bitmarkId := fs.String("bitmark-id", "", "bitmark to offer")
+	receiver := fs.String("receiver", "", "account number of the receiver")
+	submit := fs.Bool("submit", false, "submit the offer to the API")
+	extraInfo := fs.String("extra-info", "", "JSON attached to the submitted offer")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "bitmark-id", "receiver"); err != nil {
+		return err
+	}
+
+	var extra interface{}
+	if *extraInfo != "" {
+		if err := json.Unmarshal([]byte(*extraInfo), &extra); err != nil {
+			return usageError(fmt.Sprintf("invalid --extra-info: %s", err))
+		}
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+
+	record, err := client.SignTransferOffer(acct, *bitmarkId, *receiver, true)
+	if err != nil {
+		return err
+	}
+
+	result := offerResult{Record: record}
+	if *submit {
+		if result.OfferId, err = client.SubmitTransferOffer(acct, record, extra); err != nil {
+			return err
+		}
+	}
+
+	output(result, func(w io.Writer) {
+		if result.OfferId != "" {
+			fmt.Fprintf(w, "offer id:\t%s\n", result.OfferId)
+		}
+		fmt.Fprintf(w, "record:\t%s\n", record)
+	})
+	return nil
+}
+
+func runOfferList(fs *flag.FlagSet, args []string) error {
+	direction := fs.String("direction", string(sdk.OfferIncoming), "incoming or outgoing")
+	status := fs.String("status", "", "filter by the status of the offers")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	dir := sdk.TransferOfferDirection(*direction)
+	if dir != sdk.OfferIncoming && dir != sdk.OfferOutgoing {
+		return usageError(fmt.Sprintf("unknown direction: %s", *direction))
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+
+	offers, err := client.ListTransferOffers(acct, dir, sdk.TransferOfferStatus(*status))
+	if err != nil {
+		return err
+	}
+
+	output(offers, func(w io.Writer) {
+		fmt.Fprintln(w, "OFFER ID\tBITMARK ID\tFROM\tTO\tSTATUS")
+		for _, o := range offers {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", o.Id, o.BitmarkId, o.From, o.To, o.Status)
+		}
+	})
+	return nil
+}
+
+func runOfferAccept(fs *flag.FlagSet, args []string) error {
+	return completeOffer(fs, args, sdk.ActionAccept)
+}
+
+func runOfferReject(fs *flag.FlagSet, args []string) error {
+	return completeOffer(fs, args, sdk.ActionReject)
+}
+
+func runOfferCancel(fs *flag.FlagSet, args []string) error {
+	return completeOffer(fs, args, sdk.ActionCancel)
+}
+
+func completeOffer(fs *flag.FlagSet, args []string, action sdk.TransferOfferAction) error {
+	offerId := fs.String("id", "", "offer id")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "id"); err != nil {
+		return err
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+
+	var result txResult
+	switch action {
+	case sdk.ActionAccept:
+		result.TxId, err = client.AcceptTransferOffer(acct, *offerId)
+	case sdk.ActionReject:
+		err = client.RejectTransferOffer(acct, *offerId)
+	case sdk.ActionCancel:
+		err = client.CancelTransferOffer(acct, *offerId)
+	}
+	if err != nil {
+		return err
+	}
+
+	output(result, result.print)
+	return nil
+}
+
+func runOfferCountersign(fs *flag.FlagSet, args []string) error {
+	recordFile := fs.String("record", "", "`file` of the transfer offer record signed by the sender")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required(fs, "record"); err != nil {
+		return err
+	}
+
+	dat, err := ioutil.ReadFile(*recordFile)
+	if err != nil {
+		return err
+	}
+
+	var record sdk.TransferOfferRecord
+	if err := json.Unmarshal(dat, &record); err != nil {
+		return usageError(fmt.Sprintf("invalid transfer offer record: %s", err))
+	}
+
+	acct, err := loadAccount()
+	if err != nil {
+		return err
+	}
+
+	txId, err := client.CountersignTransfer(acct, &record)
+	if err != nil {
+		return err
+	}
+
+	result := txResult{txId}
+	output(result, result.print)
+	return nil
+}
@@ -0,0 +1,190 @@
+package bitmarksdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TxStatus is the status of a transaction, and of the assets and bitmarks by their latest transaction.
+type TxStatus string
+
+const (
+	TxPending   TxStatus = "pending"
+	TxConfirmed TxStatus = "confirmed"
+
+	// reported by WaitForConfirmation only
+	TxDropped TxStatus = "dropped"
+	TxExpired TxStatus = "expired"
+)
+
+var (
+	ErrTxNotFound = errors.New("transaction not found")
+	ErrTxDropped  = errors.New("transaction dropped")
+)
+
+// TxDroppedError lists the transactions which disappeared or expired while waiting for confirmation.
+// It matches ErrTxDropped by errors.Is.
+type TxDroppedError struct {
+	TxIds []string
+}
+
+func (e *TxDroppedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrTxDropped, strings.Join(e.TxIds, ", "))
+}
+
+func (e *TxDroppedError) Is(target error) bool {
+	return target == ErrTxDropped
+}
+
+type Tx struct {
+	Id          string    `json:"id"`
+	BitmarkId   string    `json:"bitmark_id"`
+	AssetId     string    `json:"asset_id"`
+	Owner       string    `json:"owner"`
+	PreviousId  string    `json:"previous_id"`
+	Status      TxStatus  `json:"status"`
+	BlockNumber uint      `json:"block_number"`
+	Offset      uint      `json:"offset"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	ConfirmedAt time.Time `json:"confirmed_at"`
+}
+
+// Confirmation is the outcome of waiting for a transaction
+type Confirmation struct {
+	TxId        string
+	Status      TxStatus
+	BlockNumber uint
+	ConfirmedAt time.Time
+
+	found bool // the transaction was found by a poll
+}
+
+// WaitOptions controls the polling of WaitForConfirmation. The interval starts at
+// Interval and grows by Multiplier up to MaxInterval.
+//
+// A transaction just submitted may not be indexed yet, so one never found is only
+// dropped when still missing after NotFoundGrace. One found before is dropped once missing.
+type WaitOptions struct {
+	Interval      time.Duration
+	MaxInterval   time.Duration
+	Multiplier    float64
+	NotFoundGrace time.Duration
+}
+
+var defaultWaitOptions = WaitOptions{
+	Interval:      2 * time.Second,
+	MaxInterval:   30 * time.Second,
+	Multiplier:    2,
+	NotFoundGrace: time.Minute,
+}
+
+func (o *WaitOptions) withDefaults() WaitOptions {
+	result := defaultWaitOptions
+	if o == nil {
+		return result
+	}
+	if o.Interval > 0 {
+		result.Interval = o.Interval
+	}
+	if o.MaxInterval > 0 {
+		result.MaxInterval = o.MaxInterval
+	}
+	if o.Multiplier >= 1 {
+		result.Multiplier = o.Multiplier
+	}
+	if o.NotFoundGrace > 0 {
+		result.NotFoundGrace = o.NotFoundGrace
+	}
+	return result
+}
+
+func (c *Client) GetTx(txId string) (*Tx, error) {
+	return c.service.getTx(txId)
+}
+
+// WaitForConfirmation polls the transactions until each is confirmed, dropped or expired,
+// and returns their confirmations in the order of txIds. The transactions which
+// disappear or expire are reported by TxDroppedError. If the context is done first,
+// the confirmations so far are returned with the context error.
+func (c *Client) WaitForConfirmation(ctx context.Context, txIds []string, opts *WaitOptions) ([]*Confirmation, error) {
+	o := opts.withDefaults()
+
+	confirmations := make([]*Confirmation, len(txIds))
+	for i, id := range txIds {
+		confirmations[i] = &Confirmation{TxId: id, Status: TxPending}
+	}
+
+	start := time.Now()
+	interval := o.Interval
+	for {
+		pending := 0
+		for _, cf := range confirmations {
+			if cf.Status != TxPending {
+				continue
+			}
+			c.pollConfirmation(cf, time.Since(start) >= o.NotFoundGrace)
+			if cf.Status == TxPending {
+				pending++
+			}
+		}
+
+		if pending == 0 {
+			break
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return confirmations, ctx.Err()
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * o.Multiplier)
+		if interval > o.MaxInterval {
+			interval = o.MaxInterval
+		}
+	}
+
+	dropped := make([]string, 0)
+	for _, cf := range confirmations {
+		if cf.Status == TxDropped || cf.Status == TxExpired {
+			dropped = append(dropped, cf.TxId)
+		}
+	}
+	if len(dropped) > 0 {
+		sort.Strings(dropped)
+		return confirmations, &TxDroppedError{dropped}
+	}
+	return confirmations, nil
+}
+
+// pollConfirmation updates the confirmation by the transaction status.
+// A failed lookup leaves it pending for the next poll, and so does a transaction
+// never found until the grace period is over.
+func (c *Client) pollConfirmation(cf *Confirmation, graceOver bool) {
+	tx, err := c.service.getTx(cf.TxId)
+	if err == ErrTxNotFound {
+		if cf.found || graceOver {
+			cf.Status = TxDropped
+		}
+		return
+	}
+	if err != nil {
+		return
+	}
+	cf.found = true
+
+	switch {
+	case tx.Status == TxConfirmed:
+		cf.Status = TxConfirmed
+		cf.BlockNumber = tx.BlockNumber
+		cf.ConfirmedAt = tx.ConfirmedAt
+	case !tx.ExpiresAt.IsZero() && time.Now().After(tx.ExpiresAt):
+		cf.Status = TxExpired
+	}
+}
@@ -0,0 +1,136 @@
+package bitmarksdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitForConfirmation(t *testing.T) {
+	confirmedAt := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var mu sync.Mutex
+	polls := make(map[string]int)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/v1/txs/")
+
+		mu.Lock()
+		polls[id]++
+		n := polls[id]
+		mu.Unlock()
+
+		switch id {
+		case "indexed":
+			// not indexed yet at the first poll
+			if n == 1 {
+				w.WriteHeader(http.StatusNotFound)
+				writeJSON(w, &ServiceError{Code: 1000, Message: "not found"})
+				return
+			}
+			writeJSON(w, map[string]*Tx{"tx": {Id: id, Status: TxConfirmed, BlockNumber: 43}})
+		case "vanished":
+			if n > 1 {
+				w.WriteHeader(http.StatusNotFound)
+				writeJSON(w, &ServiceError{Code: 1000, Message: "not found"})
+				return
+			}
+			writeJSON(w, map[string]*Tx{"tx": {Id: id, Status: TxPending}})
+		case "confirmed":
+			tx := &Tx{Id: id, Status: TxPending}
+			if n > 2 {
+				tx = &Tx{Id: id, Status: TxConfirmed, BlockNumber: 42, ConfirmedAt: confirmedAt}
+			}
+			writeJSON(w, map[string]*Tx{"tx": tx})
+		case "expired":
+			writeJSON(w, map[string]*Tx{"tx": {Id: id, Status: TxPending, ExpiresAt: time.Now().Add(-time.Minute)}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(w, &ServiceError{Code: 1000, Message: "not found"})
+		}
+	})
+
+	client, ts := newTestClient(handler, nil)
+	defer ts.Close()
+
+	opts := &WaitOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond, NotFoundGrace: 20 * time.Millisecond}
+	confirmations, err := client.WaitForConfirmation(context.Background(), []string{"confirmed", "expired", "dropped", "indexed", "vanished"}, opts)
+
+	var dropped *TxDroppedError
+	if !errors.As(err, &dropped) || !errors.Is(err, ErrTxDropped) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dropped.TxIds) != 3 || dropped.TxIds[0] != "dropped" || dropped.TxIds[1] != "expired" || dropped.TxIds[2] != "vanished" {
+		t.Fatalf("unexpected dropped transactions: %v", dropped.TxIds)
+	}
+
+	cf := confirmations[0]
+	if cf.Status != TxConfirmed || cf.BlockNumber != 42 || !cf.ConfirmedAt.Equal(confirmedAt) {
+		t.Fatalf("unexpected confirmation: %+v", cf)
+	}
+	if confirmations[1].Status != TxExpired || confirmations[2].Status != TxDropped {
+		t.Fatalf("unexpected statuses: %s %s", confirmations[1].Status, confirmations[2].Status)
+	}
+	if cf := confirmations[3]; cf.Status != TxConfirmed || cf.BlockNumber != 43 {
+		t.Fatalf("transaction missing at the first poll: %+v", cf)
+	}
+
+	// the missing transaction was polled until the grace period was over, and the
+	// one found before was dropped at the next poll
+	mu.Lock()
+	defer mu.Unlock()
+	if polls["dropped"] < 2 || polls["vanished"] != 2 {
+		t.Fatalf("unexpected polls: %v", polls)
+	}
+}
+
+func TestWaitForConfirmationCancelled(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]*Tx{"tx": {Status: TxPending}})
+	})
+
+	client, ts := newTestClient(handler, nil)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	confirmations, err := client.WaitForConfirmation(ctx, []string{"pending"}, &WaitOptions{Interval: time.Millisecond})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if confirmations[0].Status != TxPending {
+		t.Fatalf("unexpected status: %s", confirmations[0].Status)
+	}
+}
+
+func TestWaitForConfirmationPlainNotFound(t *testing.T) {
+	// a proxy in front of the API answers with its own pages
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/unavailable") {
+			http.Error(w, "<html>502 Bad Gateway</html>", http.StatusBadGateway)
+			return
+		}
+		http.Error(w, "404 page not found", http.StatusNotFound)
+	})
+
+	client, ts := newTestClient(handler, nil)
+	defer ts.Close()
+
+	if _, err := client.service.getTx("unavailable"); err == nil || err == ErrTxNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := &WaitOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond, NotFoundGrace: 10 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := client.WaitForConfirmation(ctx, []string{"missing"}, opts)
+
+	var dropped *TxDroppedError
+	if !errors.As(err, &dropped) || len(dropped.TxIds) != 1 || dropped.TxIds[0] != "missing" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
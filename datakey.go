@@ -1,19 +1,80 @@
 package bitmarksdk
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"sync"
 
 	"golang.org/x/crypto/chacha20poly1305"
 )
 
 const (
 	AlgChaCha20Poly1305 = "chacha20poly1305"
+	AlgAES256GCM        = "aes-256-gcm"
+
+	aes256KeySize = 32
+)
+
+var ErrUnknownDataKeyAlgorithm = errors.New("unknown data key algorithm")
+
+type dataKeyAlgorithm struct {
+	generate  func() (DataKey, error)
+	fromBytes func(key []byte) (DataKey, error)
+}
+
+var (
+	dataKeyAlgorithmsLock sync.RWMutex
+	dataKeyAlgorithms     = map[string]dataKeyAlgorithm{
+		AlgChaCha20Poly1305: {
+			func() (DataKey, error) { return newChaCha20DataKey() },
+			func(key []byte) (DataKey, error) {
+				if len(key) != chacha20poly1305.KeySize {
+					return nil, ErrInvalidLength
+				}
+				return &ChaCha20DataKey{key}, nil
+			},
+		},
+		AlgAES256GCM: {
+			func() (DataKey, error) { return newAES256GCMDataKey() },
+			func(key []byte) (DataKey, error) {
+				if len(key) != aes256KeySize {
+					return nil, ErrInvalidLength
+				}
+				return &AES256GCMDataKey{key}, nil
+			},
+		},
+	}
 )
 
+// RegisterDataKeyAlgorithm makes a data key algorithm available by its name.
+// generate creates a random data key, and fromBytes restores the data key from the key bytes.
+func RegisterDataKeyAlgorithm(alg string, generate func() (DataKey, error), fromBytes func(key []byte) (DataKey, error)) {
+	dataKeyAlgorithmsLock.Lock()
+	defer dataKeyAlgorithmsLock.Unlock()
+	dataKeyAlgorithms[alg] = dataKeyAlgorithm{generate, fromBytes}
+}
+
+func lookupDataKeyAlgorithm(alg string) (dataKeyAlgorithm, error) {
+	// session data created before the algorithm was recorded
+	if alg == "" {
+		alg = AlgChaCha20Poly1305
+	}
+
+	dataKeyAlgorithmsLock.RLock()
+	defer dataKeyAlgorithmsLock.RUnlock()
+	a, ok := dataKeyAlgorithms[alg]
+	if !ok {
+		return a, fmt.Errorf("%w: %q", ErrUnknownDataKeyAlgorithm, alg)
+	}
+	return a, nil
+}
+
 type DataKey interface {
 	Encrypt(plaintext []byte) ([]byte, error)
 	Decrypt(ciphertext []byte) ([]byte, error)
@@ -73,10 +134,73 @@ func (k *ChaCha20DataKey) Algorithm() string {
 	return AlgChaCha20Poly1305
 }
 
+type AES256GCMDataKey struct {
+	key []byte
+}
+
+func newAES256GCMDataKey() (*AES256GCMDataKey, error) {
+	key := make([]byte, aes256KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	return &AES256GCMDataKey{key: key}, nil
+}
+
+func (k *AES256GCMDataKey) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt the plaintext using zero nonce
+func (k *AES256GCMDataKey) Encrypt(plaintext []byte) ([]byte, error) {
+	aead, err := k.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	return aead.Seal(nil, nonce, plaintext, nil), nil
+}
+
+// Decrypt the ciphertext using zero nonce
+func (k *AES256GCMDataKey) Decrypt(ciphertext []byte) ([]byte, error) {
+	aead, err := k.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+func (k *AES256GCMDataKey) Bytes() []byte {
+	return k.key
+}
+
+func (k *AES256GCMDataKey) Algorithm() string {
+	return AlgAES256GCM
+}
+
+// NewDataKey creates a ChaCha20-Poly1305 data key.
 func NewDataKey() (DataKey, error) {
 	return newChaCha20DataKey()
 }
 
+// NewDataKeyWithAlgorithm creates a data key of the registered algorithm.
+func NewDataKeyWithAlgorithm(alg string) (DataKey, error) {
+	a, err := lookupDataKeyAlgorithm(alg)
+	if err != nil {
+		return nil, err
+	}
+	return a.generate()
+}
+
 type SessionData struct {
 	EncryptedDataKey []byte
 	DataKeyAlgorithm string
@@ -123,12 +247,15 @@ func createSessionData(acct *Account, key DataKey, recipientEncrPubkey []byte) (
 }
 
 func dataKeyFromSessionData(acct *Account, data *SessionData, senderEncrPubkey []byte) (DataKey, error) {
+	alg, err := lookupDataKeyAlgorithm(data.DataKeyAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
 	key, err := acct.EncrKey.Decrypt(data.EncryptedDataKey, senderEncrPubkey)
 	if err != nil {
 		return nil, fmt.Errorf("session data not for the recipient: %v", err)
 	}
 
-	// switch data.DataKeyAlgorithm to determine which algorithm to generate data key
-	// if more versions are supported in the future
-	return &ChaCha20DataKey{key}, nil
+	return alg.fromBytes(key)
 }
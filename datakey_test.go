@@ -3,6 +3,7 @@ package bitmarksdk
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"testing"
 )
 
@@ -21,6 +22,12 @@ var (
 			"Hello, world!",
 			"d7628bd23a7d180df7c8fb1852c2cfc31d101d6a629b2c50edf6b9751a",
 		},
+		dataKeyTestCase{
+			"aes-256-gcm",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"Hello, world!",
+			"86c22c51224c4b19683ca9b79ba1d4230928907330420d168e128f4a3a",
+		},
 	}
 )
 
@@ -32,9 +39,21 @@ func mustDecodeString(s string) []byte {
 	return b
 }
 
+func mustRestoreDataKey(alg, key string) DataKey {
+	a, err := lookupDataKeyAlgorithm(alg)
+	if err != nil {
+		panic(err)
+	}
+	dataKey, err := a.fromBytes(mustDecodeString(key))
+	if err != nil {
+		panic(err)
+	}
+	return dataKey
+}
+
 func TestEncryption(t *testing.T) {
 	for _, c := range dataKeyTestCases {
-		dataKey := ChaCha20DataKey{mustDecodeString(c.key)}
+		dataKey := mustRestoreDataKey(c.algorithm, c.key)
 		ciphertext, err := dataKey.Encrypt([]byte(c.plaintext))
 		if err != nil {
 			t.Error(err)
@@ -48,7 +67,7 @@ func TestEncryption(t *testing.T) {
 
 func TestDecryption(t *testing.T) {
 	for _, c := range dataKeyTestCases {
-		dataKey := ChaCha20DataKey{mustDecodeString(c.key)}
+		dataKey := mustRestoreDataKey(c.algorithm, c.key)
 		plaintext, err := dataKey.Decrypt(mustDecodeString(c.ciphertext))
 		if err != nil {
 			t.Error(err)
@@ -83,3 +102,28 @@ func TestSessionData(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSessionDataAlgorithm(t *testing.T) {
+	sender, _ := AccountFromSeed("5XEECttxvRBzxzAmuV4oh6T1FcQu4mBg8eWd9wKbf8hweXsfwtJ8sfH")
+	recipient, _ := AccountFromSeed("5XEECscX3EQvpqMH59Es92uE9KXuuFRQ5pmZsQtyJFiqLEEi7CqSpCo")
+
+	dataKey, err := NewDataKeyWithAlgorithm(AlgAES256GCM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := createSessionData(sender, dataKey, recipient.EncrKey.PublicKeyBytes())
+	restoredDataKey, err := dataKeyFromSessionData(recipient, data, sender.EncrKey.PublicKeyBytes())
+	if err != nil || restoredDataKey.Algorithm() != AlgAES256GCM || !bytes.Equal(restoredDataKey.Bytes(), dataKey.Bytes()) {
+		t.Fatalf("unexpected data key: %v", err)
+	}
+
+	data.DataKeyAlgorithm = "rot13"
+	if _, err := dataKeyFromSessionData(recipient, data, sender.EncrKey.PublicKeyBytes()); !errors.Is(err, ErrUnknownDataKeyAlgorithm) {
+		t.Fatalf("unknown algorithm not reported: %v", err)
+	}
+
+	if _, err := NewDataKeyWithAlgorithm("rot13"); !errors.Is(err, ErrUnknownDataKeyAlgorithm) {
+		t.Fatalf("unknown algorithm not reported: %v", err)
+	}
+}
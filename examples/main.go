@@ -163,8 +163,7 @@ func main() {
 		fmt.Printf("transfer offer by sender: %s\n", string(data))
 
 		// sign by receiver
-		transfer, _ := offer.Countersign(receiver)
-		txId, err := client.CountersignedTransfer(transfer)
+		txId, err := client.CountersignTransfer(receiver, offer)
 		if err != nil {
 			panic(err)
 		}
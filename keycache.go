@@ -0,0 +1,92 @@
+package bitmarksdk
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const (
+	defaultKeyCacheSize = 1024
+	defaultKeyCacheTTL  = 10 * time.Minute
+)
+
+// KeyCache stores the verified encryption public keys by account numbers.
+type KeyCache interface {
+	Get(acctNo string) ([]byte, bool)
+	Set(acctNo string, key []byte)
+}
+
+type keyCacheEntry struct {
+	acctNo    string
+	key       []byte
+	expiresAt time.Time
+}
+
+// LRUKeyCache evicts the least recently used key when the size is reached,
+// and drops keys older than the TTL.
+type LRUKeyCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+func NewKeyCache(size int, ttl time.Duration) *LRUKeyCache {
+	return &LRUKeyCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *LRUKeyCache) Get(acctNo string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[acctNo]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*keyCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, acctNo)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.key, true
+}
+
+func (c *LRUKeyCache) Set(acctNo string, key []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size <= 0 {
+		return
+	}
+
+	entry := &keyCacheEntry{acctNo, key, time.Now().Add(c.ttl)}
+	if elem, ok := c.entries[acctNo]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[acctNo] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*keyCacheEntry).acctNo)
+	}
+}
+
+func (c *LRUKeyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
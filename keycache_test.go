@@ -0,0 +1,65 @@
+package bitmarksdk
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestKeyCacheEviction(t *testing.T) {
+	cache := NewKeyCache(2, time.Minute)
+	cache.Set("a", []byte("a"))
+	cache.Set("b", []byte("b"))
+	cache.Get("a")
+	cache.Set("c", []byte("c"))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("least recently used key not evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Error("recently used key evicted")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("unexpected cache size: %d", cache.Len())
+	}
+}
+
+func TestKeyCacheExpiry(t *testing.T) {
+	cache := NewKeyCache(2, -time.Second)
+	cache.Set("a", []byte("a"))
+	if _, ok := cache.Get("a"); ok || cache.Len() != 0 {
+		t.Error("expired key returned")
+	}
+}
+
+func TestGetEncPubkey(t *testing.T) {
+	acct, _ := AccountFromSeed(testnetData.seed)
+	attacker, _ := AccountFromSeed("5XEECscX3EQvpqMH59Es92uE9KXuuFRQ5pmZsQtyJFiqLEEi7CqSpCo")
+
+	requests := 0
+	signer := attacker
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		encPubkeyHandler(acct, signer).ServeHTTP(w, r)
+	})
+
+	client, ts := newTestClient(handler, nil)
+	defer ts.Close()
+
+	if _, err := client.service.getEncPubkey(acct.AccountNumber()); err != ErrInvalidEncPubkey {
+		t.Fatalf("substituted key accepted: %v", err)
+	}
+
+	signer = acct
+	for i := 0; i < 2; i++ {
+		key, err := client.service.getEncPubkey(acct.AccountNumber())
+		if err != nil || !bytes.Equal(key, acct.EncrKey.PublicKeyBytes()) {
+			t.Fatalf("unexpected key: %v", err)
+		}
+	}
+
+	if requests != 2 {
+		t.Fatalf("verified key not cached: %d requests", requests)
+	}
+}
@@ -0,0 +1,119 @@
+package bitmarksdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrLeaseExpirationMissing is returned when a lease is decoded without its expiration time.
+var ErrLeaseExpirationMissing = errors.New("lease expiration time missing")
+
+// Lease is the access to a private asset granted by the lessor to the renter.
+type Lease struct {
+	BitmarkId string
+	AssetId   string
+	Owner     string
+	Renter    string
+	URL       string
+	SessData  *SessionData
+	Duration  uint
+	ExpiresAt time.Time
+}
+
+type encodedLease struct {
+	assetAccessMeta
+	BitmarkId string `json:"bitmark_id"`
+	AssetId   string `json:"asset_id"`
+	Owner     string `json:"owner"`
+	Renter    string `json:"renter"`
+	Duration  uint   `json:"duration"`
+	ExpTime   *int64 `json:"expiration_time"`
+}
+
+func (l *Lease) UnmarshalJSON(data []byte) error {
+	var aux encodedLease
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.ExpTime == nil {
+		return ErrLeaseExpirationMissing
+	}
+
+	*l = Lease{
+		BitmarkId: aux.BitmarkId,
+		AssetId:   aux.AssetId,
+		Owner:     aux.Owner,
+		Renter:    aux.Renter,
+		URL:       aux.URL,
+		SessData:  aux.SessData,
+		Duration:  aux.Duration,
+		ExpiresAt: time.Unix(*aux.ExpTime, 0).UTC(),
+	}
+	return nil
+}
+
+func (l Lease) MarshalJSON() ([]byte, error) {
+	expTime := l.ExpiresAt.Unix()
+	return json.Marshal(&encodedLease{
+		assetAccessMeta: assetAccessMeta{l.URL, l.SessData},
+		BitmarkId:       l.BitmarkId,
+		AssetId:         l.AssetId,
+		Owner:           l.Owner,
+		Renter:          l.Renter,
+		Duration:        l.Duration,
+		ExpTime:         &expTime,
+	})
+}
+
+func (l *Lease) Expired() bool {
+	return !time.Now().Before(l.ExpiresAt)
+}
+
+// renewalDays returns the days to grant from now for the lease to expire the days after
+// its current expiry, or after now if it already expired. The API grants whole days,
+// so the new expiry is rounded to the nearest day, by at most 12 hours.
+func (l *Lease) renewalDays(days uint) uint {
+	from := l.ExpiresAt
+	if now := time.Now(); from.Before(now) {
+		from = now
+	}
+	expiry := from.Add(time.Duration(days) * 24 * time.Hour)
+	return uint(math.Round(time.Until(expiry).Hours() / 24))
+}
+
+// LeaseExpiredError is returned when the asset is accessed by an expired lease.
+type LeaseExpiredError struct {
+	BitmarkId string
+	ExpiresAt time.Time
+}
+
+func (e *LeaseExpiredError) Error() string {
+	return fmt.Sprintf("lease of bitmark %s expired at %s", e.BitmarkId, e.ExpiresAt.Format(time.RFC3339))
+}
+
+// LeaseNotFoundError is returned when the lessor granted no lease of the bitmark to the renter.
+type LeaseNotFoundError struct {
+	BitmarkId string
+	Renter    string
+}
+
+func (e *LeaseNotFoundError) Error() string {
+	return fmt.Sprintf("lease of bitmark %s to %s not found", e.BitmarkId, e.Renter)
+}
+
+// BulkLeaseError reports the renters failed to be granted by RentBitmarkToMany.
+type BulkLeaseError map[string]error
+
+func (e BulkLeaseError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for renter, err := range e {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", renter, err))
+	}
+	sort.Strings(msgs)
+	return "lease failed for " + strings.Join(msgs, "; ")
+}
@@ -0,0 +1,168 @@
+package bitmarksdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLeaseJSON(t *testing.T) {
+	var lease Lease
+	data := `{"bitmark_id":"bid","asset_id":"aid","owner":"lessor","url":"https://assets","duration":30,"expiration_time":1514764800}`
+	if err := json.Unmarshal([]byte(data), &lease); err != nil {
+		t.Fatal(err)
+	}
+
+	if lease.BitmarkId != "bid" || lease.Owner != "lessor" || lease.URL != "https://assets" || lease.Duration != 30 {
+		t.Fatalf("unexpected lease: %+v", lease)
+	}
+	if !lease.ExpiresAt.Equal(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)) || !lease.Expired() {
+		t.Fatalf("unexpected expiry: %s", lease.ExpiresAt)
+	}
+
+	b, _ := json.Marshal(lease)
+	var restored Lease
+	json.Unmarshal(b, &restored)
+	if restored != lease {
+		t.Fatalf("expected %+v, got %+v", lease, restored)
+	}
+}
+
+func TestLeaseWithoutExpiration(t *testing.T) {
+	var lease Lease
+	data := `{"bitmark_id":"bid","asset_id":"aid","owner":"lessor","url":"https://assets","duration":30}`
+	if err := json.Unmarshal([]byte(data), &lease); err != ErrLeaseExpirationMissing {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLeaseRenewalDays(t *testing.T) {
+	cases := []struct {
+		remaining time.Duration
+		days      uint
+		expected  uint
+	}{
+		{30 * time.Hour, 2, 3}, // 3.25 days
+		{42 * time.Hour, 1, 3}, // 2.75 days
+		{10 * time.Hour, 0, 0}, // 0.42 days
+		{-5 * time.Hour, 2, 2}, // expired, renewed from now
+		{30 * 24 * time.Hour, 30, 60},
+	}
+	for _, c := range cases {
+		lease := Lease{ExpiresAt: time.Now().Add(c.remaining)}
+		if days := lease.renewalDays(c.days); days != c.expected {
+			t.Errorf("%s remaining renewed by %d days: expected %d, got %d", c.remaining, c.days, c.expected, days)
+		}
+	}
+
+	if lease := (Lease{ExpiresAt: time.Now().Add(36 * time.Hour)}); lease.Expired() {
+		t.Error("lease expired")
+	}
+}
+
+func TestRenewLease(t *testing.T) {
+	lessor, _ := AccountFromSeed("5XEECscX3EQvpqMH59Es92uE9KXuuFRQ5pmZsQtyJFiqLEEi7CqSpCo")
+	renter, _ := AccountFromSeed(testnetData.seed)
+
+	dataKey, _ := NewDataKey()
+	sessData, _ := createSessionData(lessor, dataKey, lessor.EncrKey.PublicKeyBytes())
+
+	var days uint
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/bitmarks/"+testBitmarkId+"/asset", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"url": "", "session_data": sessData, "sender": lessor.AccountNumber()})
+	})
+	mux.HandleFunc("/v2/leases/granted", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string][]*Lease{"leases": {
+			{BitmarkId: testBitmarkId, Owner: lessor.AccountNumber(), Renter: renter.AccountNumber(), ExpiresAt: time.Now().Add(42 * time.Hour)},
+		}})
+	})
+	mux.HandleFunc("/v2/leases/"+testBitmarkId, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Days uint `json:"days"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		days = body.Days
+	})
+	for _, acct := range []*Account{lessor, renter} {
+		mux.Handle("/"+acct.AccountNumber(), encPubkeyHandler(acct, acct))
+	}
+
+	client, ts := newTestClient(mux, nil)
+	defer ts.Close()
+
+	if err := client.RenewLease(lessor, testBitmarkId, renter.AccountNumber(), 30); err != nil {
+		t.Fatal(err)
+	}
+	if days != 32 {
+		t.Fatalf("renewed for %d days", days)
+	}
+
+	err := client.RenewLease(lessor, testBitmarkId, lessor.AccountNumber(), 30)
+	if nf, ok := err.(*LeaseNotFoundError); !ok || nf.Renter != lessor.AccountNumber() {
+		t.Fatalf("missing lease not reported: %v", err)
+	}
+}
+
+func TestDownloadAssetByExpiredLease(t *testing.T) {
+	client := NewClient(&Config{Network: "testnet"})
+	renter, _ := AccountFromSeed(testnetData.seed)
+
+	_, err := client.DownloadAssetByLease(renter, &Lease{BitmarkId: "bid", ExpiresAt: time.Now().Add(-time.Hour)})
+	if _, ok := err.(*LeaseExpiredError); !ok {
+		t.Fatalf("expired lease not reported: %v", err)
+	}
+}
+
+func TestRentBitmarkBySecondaryOwner(t *testing.T) {
+	uploader, _ := AccountFromSeed("5XEECttxvRBzxzAmuV4oh6T1FcQu4mBg8eWd9wKbf8hweXsfwtJ8sfH")
+	lessor, _ := AccountFromSeed("5XEECscX3EQvpqMH59Es92uE9KXuuFRQ5pmZsQtyJFiqLEEi7CqSpCo")
+	renter1 := newTestAccount(Testnet)
+	renter2, _ := AccountFromSeed(testnetData.seed)
+	accounts := []*Account{uploader, lessor, renter1, renter2}
+
+	dataKey, _ := NewDataKey()
+	sessData, _ := createSessionData(uploader, dataKey, lessor.EncrKey.PublicKeyBytes())
+
+	granted := make(map[string]*SessionData)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/bitmarks/"+testBitmarkId+"/asset", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"url": "", "session_data": sessData, "sender": uploader.AccountNumber()})
+	})
+	mux.HandleFunc("/v2/leases/"+testBitmarkId, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Renter   string       `json:"renter"`
+			SessData *SessionData `json:"session_data"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		granted[body.Renter] = body.SessData
+	})
+	for _, acct := range accounts {
+		mux.Handle("/"+acct.AccountNumber(), encPubkeyHandler(acct, acct))
+	}
+
+	client, ts := newTestClient(mux, nil)
+	defer ts.Close()
+
+	if err := client.RentBitmarkToMany(lessor, testBitmarkId, []string{renter1.AccountNumber(), renter2.AccountNumber()}, 30); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, renter := range []*Account{renter1, renter2} {
+		data, ok := granted[renter.AccountNumber()]
+		if !ok {
+			t.Fatalf("lease not granted to %s", renter.AccountNumber())
+		}
+		restored, err := dataKeyFromSessionData(renter, data, lessor.EncrKey.PublicKeyBytes())
+		if err != nil || !bytes.Equal(restored.Bytes(), dataKey.Bytes()) {
+			t.Fatalf("renter cannot recover the data key: %v", err)
+		}
+	}
+
+	err := client.RentBitmarkToMany(lessor, testBitmarkId, []string{"unknown"}, 30)
+	if errs, ok := err.(BulkLeaseError); !ok || errs["unknown"] == nil {
+		t.Fatalf("failed renter not reported: %v", err)
+	}
+}
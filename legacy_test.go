@@ -0,0 +1,151 @@
+package bitmarksdk
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAPI records the requests and serves the minimal responses of the API, key and asset servers
+type fakeAPI struct {
+	sync.Mutex
+	owner    string
+	content  []byte
+	requests []string
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	f.Unlock()
+
+	switch {
+	case r.URL.Path == "/v1/issue":
+		writeJSON(w, []map[string]string{{"txId": "issue"}})
+	case r.URL.Path == "/v2/transfer":
+		writeJSON(w, []map[string]string{{"txId": "transfer"}})
+	case r.URL.Path == "/content":
+		w.Header().Set("Content-Disposition", `attachment; filename="test.txt"`)
+		w.Write(f.content)
+	case strings.HasSuffix(r.URL.Path, "/asset"):
+		writeJSON(w, map[string]interface{}{"url": "http://" + r.Host + "/content", "sender": f.owner})
+	case strings.HasPrefix(r.URL.Path, "/v1/bitmarks/"):
+		writeJSON(w, map[string]interface{}{
+			"bitmark": &Bitmark{Id: testBitmarkId, HeadId: testBitmarkId, Owner: f.owner},
+			"asset":   &Asset{Fingerprint: fingerprintFromBytes(f.content)},
+		})
+	case strings.HasPrefix(r.URL.Path, "/v1/encryption_keys/"):
+		writeJSON(w, map[string]string{})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeAPI) reset() []string {
+	f.Lock()
+	defer f.Unlock()
+	requests := f.requests
+	f.requests = nil
+	return requests
+}
+
+func TestLegacyParity(t *testing.T) {
+	owner, _ := AccountFromSeed(testnetData.seed)
+	receiver, _ := AccountFromSeed("5XEECscX3EQvpqMH59Es92uE9KXuuFRQ5pmZsQtyJFiqLEEi7CqSpCo")
+	api := &fakeAPI{owner: owner.AccountNumber(), content: []byte("Hello, world!")}
+	ts := httptest.NewServer(api)
+	defer ts.Close()
+
+	cfg := &Config{HTTPClient: ts.Client(), APIEndpoint: ts.URL, KeyEndpoint: ts.URL}
+	SetDefaultConfig(cfg)
+	defer SetDefaultConfig(&Config{HTTPClient: &http.Client{Timeout: 3 * time.Second}})
+
+	clientCfg := *cfg
+	clientCfg.Network = "testnet"
+	client := NewClient(&clientCfg)
+
+	legacy, err := NewSessionWithConfig(cfg).RestoreAccountFromSeed(testnetData.seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetId := AssetIdFromFingerprint(validFingerprint)
+
+	ops := []struct {
+		name   string
+		legacy func(acct *Account) (interface{}, error)
+		client func() (interface{}, error)
+	}{
+		{
+			"issue",
+			func(acct *Account) (interface{}, error) { return acct.IssueByAssetId(assetId, 1) },
+			func() (interface{}, error) { return client.IssueByAssetId(owner, assetId, 1) },
+		},
+		{
+			"transfer",
+			func(acct *Account) (interface{}, error) {
+				return acct.TransferBitmark(testBitmarkId, receiver.AccountNumber())
+			},
+			func() (interface{}, error) { return client.Transfer(owner, testBitmarkId, receiver.AccountNumber()) },
+		},
+		{
+			"download",
+			func(acct *Account) (interface{}, error) {
+				_, content, err := acct.DownloadAsset(testBitmarkId)
+				return content, err
+			},
+			func() (interface{}, error) {
+				_, content, err := client.DownloadAsset(owner, testBitmarkId)
+				return content, err
+			},
+		},
+	}
+
+	// accounts restored by a session and by the package-level constructors
+	for _, acct := range []*Account{legacy, owner} {
+		for _, op := range ops {
+			legacyResult, legacyErr := op.legacy(acct)
+			legacyRequests := api.reset()
+
+			clientResult, clientErr := op.client()
+			clientRequests := api.reset()
+
+			if legacyErr != nil || clientErr != nil {
+				t.Fatalf("%s: %v, %v", op.name, legacyErr, clientErr)
+			}
+			if !reflect.DeepEqual(legacyResult, clientResult) {
+				t.Errorf("%s: results differ: %v, %v", op.name, legacyResult, clientResult)
+			}
+			if !reflect.DeepEqual(legacyRequests, clientRequests) {
+				t.Errorf("%s: requests differ: %v, %v", op.name, legacyRequests, clientRequests)
+			}
+		}
+	}
+
+	api.reset()
+	if _, err := NewSessionWithConfig(cfg).CreateAccount(Testnet); err != nil {
+		t.Fatal(err)
+	}
+	if requests := api.reset(); len(requests) != 1 || !strings.HasPrefix(requests[0], "POST /v1/encryption_keys/") {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+}
+
+func TestLegacyInvalidConfig(t *testing.T) {
+	invalid := &Config{DataKeyAlgorithm: "rot13"}
+
+	if err := SetDefaultConfig(invalid); !errors.Is(err, ErrUnknownDataKeyAlgorithm) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := defaultClient(Testnet); err != nil {
+		t.Fatalf("default config replaced: %v", err)
+	}
+
+	if _, err := NewSessionWithConfig(invalid).CreateAccount(Testnet); !errors.Is(err, ErrUnknownDataKeyAlgorithm) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package bitmarksdk
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/sha3"
+)
+
+// messagePrefix starts every signed message. Its first byte is the varint of no record tag,
+// and no API request message starts with a NUL, so a signed message is neither
+// a packed transaction nor a signed request.
+const messagePrefix = "\x00Bitmark Signed Message\n"
+
+var (
+	ErrMessagePurposeEmpty     = errors.New("message purpose not set")
+	ErrMessageSignatureInvalid = errors.New("malformed message signature")
+)
+
+// MessageSignature is the signature of a message by SignMessage. Its text form is
+// the base58 of the signature followed by a checksum.
+type MessageSignature []byte
+
+func (s MessageSignature) String() string {
+	checksum := sha3.Sum256(s)
+	return toBase58(append(append([]byte{}, s...), checksum[:checksumLength]...))
+}
+
+func ParseMessageSignature(s string) (MessageSignature, error) {
+	b := fromBase58(s)
+	if len(b) != ed25519.SignatureSize+checksumLength {
+		return nil, ErrMessageSignatureInvalid
+	}
+
+	sig := b[:ed25519.SignatureSize]
+	checksum := sha3.Sum256(sig)
+	if !bytes.Equal(checksum[:checksumLength], b[ed25519.SignatureSize:]) {
+		return nil, ErrMessageSignatureInvalid
+	}
+	return MessageSignature(sig), nil
+}
+
+// packMessage binds the message to its purpose, e.g. "login:example.com", so a
+// signature for a purpose is not valid for another
+func packMessage(purpose string, msg []byte) []byte {
+	packed := []byte(messagePrefix)
+	packed = appendString(packed, purpose)
+	return appendBytes(packed, msg)
+}
+
+// SignMessage signs the message for the purpose by the auth key of the account.
+func (acct *Account) SignMessage(purpose string, msg []byte) (MessageSignature, error) {
+	if purpose == "" {
+		return nil, ErrMessagePurposeEmpty
+	}
+	return acct.AuthKey.Sign(packMessage(purpose, msg)), nil
+}
+
+// VerifyMessage verifies the signature of the message for the purpose by the account.
+func VerifyMessage(accountNumber, purpose string, msg []byte, sig MessageSignature) error {
+	if purpose == "" {
+		return ErrMessagePurposeEmpty
+	}
+
+	an, err := ParseAccountNumber(accountNumber)
+	if err != nil {
+		return err
+	}
+	if an.Algorithm != AlgEd25519 || len(sig) != ed25519.SignatureSize {
+		return ErrInvalidSignature
+	}
+
+	if !ed25519.Verify(an.PublicKey, packMessage(purpose, msg), sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
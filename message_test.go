@@ -0,0 +1,73 @@
+package bitmarksdk
+
+import (
+	"testing"
+)
+
+func TestSignMessage(t *testing.T) {
+	acct := newTestAccount(Testnet)
+	challenge := []byte("nonce 8f3a")
+
+	sig, err := acct.SignMessage("login:example.com", challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseMessageSignature(sig.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyMessage(acct.AccountNumber(), "login:example.com", challenge, parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyMessage(acct.AccountNumber(), "login:example.org", challenge, sig); err != ErrInvalidSignature {
+		t.Fatalf("signature valid for another purpose: %v", err)
+	}
+	if err := VerifyMessage(acct.AccountNumber(), "login:example.com", []byte("nonce 8f3b"), sig); err != ErrInvalidSignature {
+		t.Fatalf("signature valid for another message: %v", err)
+	}
+	if err := VerifyMessage(newTestAccount(Testnet).AccountNumber(), "login:example.com", challenge, sig); err != ErrInvalidSignature {
+		t.Fatalf("signature valid for another account: %v", err)
+	}
+
+	// the purpose and the message are length-prefixed
+	if err := VerifyMessage(acct.AccountNumber(), "login:example.co", append([]byte("m"), challenge...), sig); err != ErrInvalidSignature {
+		t.Fatalf("signature valid for another split: %v", err)
+	}
+
+	if _, err := acct.SignMessage("", challenge); err != ErrMessagePurposeEmpty {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSignMessageNotTransaction(t *testing.T) {
+	acct := newTestAccount(Livenet)
+	packed := packMessage("transfer", []byte("bitmark"))
+	if packed[0] == byte(assetTag) || packed[0] == byte(issueTag) ||
+		packed[0] == byte(transferUnratifiedTag) || packed[0] == byte(transferCountersignedTag) {
+		t.Fatalf("message starts with a record tag: %x", packed[0])
+	}
+
+	sig, _ := acct.SignMessage("transfer", []byte("bitmark"))
+	if string(sig) == string(acct.AuthKey.Sign([]byte("bitmark"))) {
+		t.Fatal("message signed without the prefix")
+	}
+}
+
+func TestParseMessageSignature(t *testing.T) {
+	sig, _ := newTestAccount(Testnet).SignMessage("login", []byte("challenge"))
+	text := sig.String()
+
+	tampered := []byte(text)
+	if tampered[0] == '2' {
+		tampered[0] = '3'
+	} else {
+		tampered[0] = '2'
+	}
+	for _, s := range []string{"", "abc", string(tampered)} {
+		if _, err := ParseMessageSignature(s); err != ErrMessageSignatureInvalid {
+			t.Errorf("unexpected error of %q: %v", s, err)
+		}
+	}
+}
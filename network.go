@@ -0,0 +1,135 @@
+package bitmarksdk
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownNetwork        = errors.New("unknown network")
+	ErrInvalidNetworkProfile = errors.New("invalid network profile")
+	ErrNetworkRegistered     = errors.New("network already registered")
+)
+
+// NetworkProfile describes the endpoints of a Bitmark network. The accounts of the
+// network are encoded by the key variant of KeyNetwork, which is Livenet or Testnet.
+//
+// The endpoints are host[:port], reached by Scheme, or full URLs. Scheme is https if not set.
+type NetworkProfile struct {
+	Name       string
+	KeyNetwork Network
+	Scheme     string
+
+	APIEndpoint string
+	KeyEndpoint string
+
+	// AssetEndpoint serves the asset contents whose URLs are given by path only.
+	// APIEndpoint is used if not set.
+	AssetEndpoint string
+}
+
+var (
+	networksLock sync.RWMutex
+	networks     = map[string]*NetworkProfile{
+		"livenet": {
+			Name:        "livenet",
+			KeyNetwork:  Livenet,
+			APIEndpoint: "api.bitmark.com",
+			KeyEndpoint: "key.assets.bitmark.com",
+		},
+		"testnet": {
+			Name:        "testnet",
+			KeyNetwork:  Testnet,
+			APIEndpoint: "api.test.bitmark.com",
+			KeyEndpoint: "key.assets.test.bitmark.com",
+		},
+	}
+)
+
+// RegisterNetwork adds the profile of the network by its name, which is used as
+// Config.Network. A registered network, e.g. livenet or testnet, is not replaced.
+func RegisterNetwork(p *NetworkProfile) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	profile := *p
+	networksLock.Lock()
+	defer networksLock.Unlock()
+	if _, ok := networks[p.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrNetworkRegistered, p.Name)
+	}
+	networks[p.Name] = &profile
+	return nil
+}
+
+// LookupNetwork returns a copy of the registered profile of the network.
+func LookupNetwork(name string) (*NetworkProfile, error) {
+	networksLock.RLock()
+	defer networksLock.RUnlock()
+
+	p, ok := networks[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNetwork, name)
+	}
+	profile := *p
+	return &profile, nil
+}
+
+func (p *NetworkProfile) validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidNetworkProfile, fmt.Sprintf(format, args...))
+	}
+
+	if p.Name == "" {
+		return invalid("name not set")
+	}
+	if p.KeyNetwork != Livenet && p.KeyNetwork != Testnet {
+		return invalid("key network of %s is neither livenet nor testnet", p.Name)
+	}
+	if p.Scheme != "" && p.Scheme != "http" && p.Scheme != "https" {
+		return invalid("unsupported scheme %s", p.Scheme)
+	}
+
+	for name, endpoint := range map[string]string{"api": p.APIEndpoint, "key": p.KeyEndpoint, "asset": p.AssetEndpoint} {
+		if endpoint == "" && name == "asset" {
+			continue
+		}
+		u, err := url.Parse(p.endpointURL(endpoint))
+		if err != nil || u.Host == "" {
+			return invalid("malformed %s endpoint %q", name, endpoint)
+		}
+	}
+	return nil
+}
+
+// endpointURL returns the URL of the endpoint, prefixed by the scheme if it is a host
+func (p *NetworkProfile) endpointURL(endpoint string) string {
+	if endpoint == "" || strings.Contains(endpoint, "://") {
+		return strings.TrimSuffix(endpoint, "/")
+	}
+
+	scheme := p.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	return scheme + "://" + strings.TrimSuffix(endpoint, "/")
+}
+
+func (p *NetworkProfile) APIURL() string {
+	return p.endpointURL(p.APIEndpoint)
+}
+
+func (p *NetworkProfile) KeyURL() string {
+	return p.endpointURL(p.KeyEndpoint)
+}
+
+func (p *NetworkProfile) AssetURL() string {
+	if p.AssetEndpoint == "" {
+		return p.APIURL()
+	}
+	return p.endpointURL(p.AssetEndpoint)
+}
@@ -0,0 +1,90 @@
+package bitmarksdk
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNetworkProfile(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]*Tx{"tx": {Id: strings.TrimPrefix(r.URL.Path, "/v1/txs/"), Status: TxConfirmed}})
+	}))
+	defer ts.Close()
+
+	profile := &NetworkProfile{
+		Name:        "local",
+		KeyNetwork:  Testnet,
+		Scheme:      "http",
+		APIEndpoint: strings.TrimPrefix(ts.URL, "http://"),
+		KeyEndpoint: "localhost:8091",
+	}
+	if err := RegisterNetwork(profile); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		networksLock.Lock()
+		delete(networks, profile.Name)
+		networksLock.Unlock()
+	}()
+
+	if err := RegisterNetwork(profile); !errors.Is(err, ErrNetworkRegistered) {
+		t.Fatalf("unexpected error of a registered network: %v", err)
+	}
+	if err := RegisterNetwork(&NetworkProfile{Name: "livenet", KeyNetwork: Testnet, APIEndpoint: "localhost", KeyEndpoint: "localhost"}); !errors.Is(err, ErrNetworkRegistered) {
+		t.Fatalf("unexpected error of livenet: %v", err)
+	}
+
+	client := NewClient(&Config{Network: "local"})
+	if client.Network != Testnet || client.service.apiEndpoint != ts.URL || client.service.keyEndpoint != "http://localhost:8091" {
+		t.Fatalf("unexpected client: %s %s %s", client.Network, client.service.apiEndpoint, client.service.keyEndpoint)
+	}
+	if tx, err := client.GetTx("tx"); err != nil || tx.Id != "tx" {
+		t.Fatalf("unexpected result: %v %v", tx, err)
+	}
+
+	legacy, err := NewAPIClientWithProfile(profile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy.service.assetEndpoint != ts.URL {
+		t.Fatalf("unexpected asset endpoint: %s", legacy.service.assetEndpoint)
+	}
+
+	if p, err := LookupNetwork("livenet"); err != nil || p.APIURL() != "https://api.bitmark.com" {
+		t.Fatalf("unexpected livenet profile: %+v %v", p, err)
+	}
+	if _, err := LookupNetwork("devnet"); !errors.Is(err, ErrUnknownNetwork) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNewClientWithProfileErrors(t *testing.T) {
+	for _, p := range []*NetworkProfile{
+		nil,
+		{KeyNetwork: Testnet, APIEndpoint: "localhost", KeyEndpoint: "localhost"},
+		{Name: "bad", KeyNetwork: Network(7), APIEndpoint: "localhost", KeyEndpoint: "localhost"},
+		{Name: "bad", KeyNetwork: Testnet, Scheme: "ftp", APIEndpoint: "localhost", KeyEndpoint: "localhost"},
+		{Name: "bad", KeyNetwork: Testnet, APIEndpoint: "localhost"},
+	} {
+		if _, err := NewClientWithProfile(p, nil); !errors.Is(err, ErrInvalidNetworkProfile) {
+			t.Errorf("unexpected error of %+v: %v", p, err)
+		}
+	}
+
+	profile, _ := LookupNetwork("testnet")
+	if _, err := NewClientWithProfile(profile, &Config{DataKeyAlgorithm: "rot13"}); !errors.Is(err, ErrUnknownDataKeyAlgorithm) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewClientUnknownNetwork(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "unknown network: devnet" {
+			t.Fatalf("unexpected panic: %v", r)
+		}
+	}()
+	NewClient(&Config{Network: "devnet"})
+}
@@ -0,0 +1,236 @@
+package bitmarksdk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestEvent describes an API request to the observers. It carries no headers,
+// query strings or bodies, so no signatures, tokens or session data are exposed.
+type RequestEvent struct {
+	// Operation is the name of the call, e.g. issue, transfer or addSessionData.
+	Operation string
+	Method    string
+	Path      string
+
+	// set when the request ends
+	StatusCode int
+	ErrorCode  int // code of the ServiceError, 0 if none
+	Latency    time.Duration
+	Retries    int
+	Err        error
+}
+
+// Observer is notified of the start and the end of every API request.
+// It is called synchronously, and must be safe for concurrent use.
+type Observer interface {
+	RequestStarted(e *RequestEvent)
+	RequestFinished(e *RequestEvent)
+}
+
+func (s *Service) observeStart(e *RequestEvent) {
+	for _, o := range s.observers {
+		o.RequestStarted(e)
+	}
+}
+
+func (s *Service) observeFinish(e *RequestEvent, err error) {
+	var se *ServiceError
+	if errors.As(err, &se) {
+		e.ErrorCode = se.Code
+	}
+
+	// the url of a transport error may carry signed query parameters
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		err = fmt.Errorf("%s %s: %w", ue.Op, e.Path, ue.Err)
+	}
+	e.Err = err
+
+	for _, o := range s.observers {
+		o.RequestFinished(e)
+	}
+}
+
+// LogObserver writes a line of key=value pairs for every finished request.
+type LogObserver struct {
+	logger *log.Logger
+}
+
+// NewLogObserver logs by the logger, or the standard logger if nil.
+func NewLogObserver(logger *log.Logger) *LogObserver {
+	if logger == nil {
+		logger = log.New(log.Writer(), log.Prefix(), log.Flags())
+	}
+	return &LogObserver{logger}
+}
+
+func (o *LogObserver) RequestStarted(e *RequestEvent) {}
+
+func (o *LogObserver) RequestFinished(e *RequestEvent) {
+	line := fmt.Sprintf("op=%s method=%s path=%s status=%d latency=%s retries=%d",
+		e.Operation, e.Method, e.Path, e.StatusCode, e.Latency, e.Retries)
+	if e.ErrorCode != 0 {
+		line += fmt.Sprintf(" code=%d", e.ErrorCode)
+	}
+	if e.Err != nil {
+		line += fmt.Sprintf(" error=%q", e.Err.Error())
+	}
+	o.logger.Println(line)
+}
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the latency histogram.
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type latencyHistogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// PrometheusObserver counts the requests by operation, and serves them
+// in the Prometheus text format.
+type PrometheusObserver struct {
+	mu       sync.Mutex
+	buckets  []float64
+	inFlight map[string]int64
+	requests map[[2]string]uint64 // by operation and status code
+	errors   map[[2]string]uint64 // by operation and error code
+	retries  map[string]uint64
+	latency  map[string]*latencyHistogram
+}
+
+// NewPrometheusObserver uses DefaultLatencyBuckets if buckets are not given.
+func NewPrometheusObserver(buckets ...float64) *PrometheusObserver {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &PrometheusObserver{
+		buckets:  buckets,
+		inFlight: make(map[string]int64),
+		requests: make(map[[2]string]uint64),
+		errors:   make(map[[2]string]uint64),
+		retries:  make(map[string]uint64),
+		latency:  make(map[string]*latencyHistogram),
+	}
+}
+
+func (o *PrometheusObserver) RequestStarted(e *RequestEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.inFlight[e.Operation]++
+}
+
+func (o *PrometheusObserver) RequestFinished(e *RequestEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.inFlight[e.Operation]--
+	o.requests[[2]string{e.Operation, fmt.Sprint(e.StatusCode)}]++
+	if e.Err != nil {
+		o.errors[[2]string{e.Operation, fmt.Sprint(e.ErrorCode)}]++
+	}
+	o.retries[e.Operation] += uint64(e.Retries)
+
+	h, ok := o.latency[e.Operation]
+	if !ok {
+		h = &latencyHistogram{counts: make([]uint64, len(o.buckets))}
+		o.latency[e.Operation] = h
+	}
+	seconds := e.Latency.Seconds()
+	for i, le := range o.buckets {
+		if seconds <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (o *PrometheusObserver) WriteTo(w io.Writer) (int64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var b strings.Builder
+
+	// every operation is counted in flight when started
+	ops := make([]string, 0, len(o.inFlight))
+	for op := range o.inFlight {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	b.WriteString("# HELP bitmark_sdk_requests_in_flight Bitmark API requests in flight.\n")
+	b.WriteString("# TYPE bitmark_sdk_requests_in_flight gauge\n")
+	for _, op := range ops {
+		fmt.Fprintf(&b, "bitmark_sdk_requests_in_flight{operation=%q} %d\n", op, o.inFlight[op])
+	}
+
+	b.WriteString("# HELP bitmark_sdk_requests_total Bitmark API requests by status code.\n")
+	b.WriteString("# TYPE bitmark_sdk_requests_total counter\n")
+	for _, k := range sortedPairs(o.requests) {
+		fmt.Fprintf(&b, "bitmark_sdk_requests_total{operation=%q,status=%q} %d\n", k[0], k[1], o.requests[k])
+	}
+
+	b.WriteString("# HELP bitmark_sdk_request_errors_total Failed Bitmark API requests by service error code.\n")
+	b.WriteString("# TYPE bitmark_sdk_request_errors_total counter\n")
+	for _, k := range sortedPairs(o.errors) {
+		fmt.Fprintf(&b, "bitmark_sdk_request_errors_total{operation=%q,code=%q} %d\n", k[0], k[1], o.errors[k])
+	}
+
+	b.WriteString("# HELP bitmark_sdk_request_retries_total Retries of Bitmark API requests.\n")
+	b.WriteString("# TYPE bitmark_sdk_request_retries_total counter\n")
+	for _, op := range ops {
+		fmt.Fprintf(&b, "bitmark_sdk_request_retries_total{operation=%q} %d\n", op, o.retries[op])
+	}
+
+	b.WriteString("# HELP bitmark_sdk_request_duration_seconds Latency of Bitmark API requests.\n")
+	b.WriteString("# TYPE bitmark_sdk_request_duration_seconds histogram\n")
+	for _, op := range ops {
+		h, ok := o.latency[op]
+		if !ok {
+			continue
+		}
+		for i, le := range o.buckets {
+			fmt.Fprintf(&b, "bitmark_sdk_request_duration_seconds_bucket{operation=%q,le=\"%g\"} %d\n", op, le, h.counts[i])
+		}
+		fmt.Fprintf(&b, "bitmark_sdk_request_duration_seconds_bucket{operation=%q,le=\"+Inf\"} %d\n", op, h.count)
+		fmt.Fprintf(&b, "bitmark_sdk_request_duration_seconds_sum{operation=%q} %g\n", op, h.sum)
+		fmt.Fprintf(&b, "bitmark_sdk_request_duration_seconds_count{operation=%q} %d\n", op, h.count)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP serves the metrics to a Prometheus scraper.
+func (o *PrometheusObserver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	o.WriteTo(w)
+}
+
+func sortedPairs(m map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
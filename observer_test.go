@@ -0,0 +1,89 @@
+package bitmarksdk
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingObserver struct {
+	sync.Mutex
+	started  []*RequestEvent
+	finished []*RequestEvent
+}
+
+func (o *recordingObserver) RequestStarted(e *RequestEvent) {
+	o.Lock()
+	defer o.Unlock()
+	o.started = append(o.started, e)
+}
+
+func (o *recordingObserver) RequestFinished(e *RequestEvent) {
+	o.Lock()
+	defer o.Unlock()
+	o.finished = append(o.finished, e)
+}
+
+func TestObservers(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/txs/known":
+			writeJSON(w, map[string]*Tx{"tx": {Id: "known", Status: TxConfirmed}})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, &ServiceError{Code: 1001, Message: "invalid"})
+		}
+	})
+
+	rec := &recordingObserver{}
+	prom := NewPrometheusObserver()
+	var logs bytes.Buffer
+	client, ts := newTestClient(handler, &Config{
+		Observers: []Observer{rec, prom, NewLogObserver(log.New(&logs, "", 0))},
+	})
+	defer ts.Close()
+
+	if _, err := client.GetTx("known"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.QueryBitmarks(&BitmarkFilter{Owner: "secret-owner"}); err == nil {
+		t.Fatal("error not returned")
+	}
+
+	if len(rec.started) != 2 || len(rec.finished) != 2 {
+		t.Fatalf("unexpected notifications: %d started, %d finished", len(rec.started), len(rec.finished))
+	}
+	e := rec.finished[0]
+	if e.Operation != "getTx" || e.Method != "GET" || e.Path != "/v1/txs/known" || e.StatusCode != 200 || e.Err != nil {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+	e = rec.finished[1]
+	if e.Operation != "queryBitmarks" || e.StatusCode != 400 || e.ErrorCode != 1001 || e.Err == nil {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+
+	if strings.Contains(logs.String(), "secret-owner") {
+		t.Fatalf("query string logged: %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "op=queryBitmarks method=GET path=/v1/bitmarks status=400") ||
+		!strings.Contains(logs.String(), "code=1001") {
+		t.Fatalf("unexpected log: %s", logs.String())
+	}
+
+	var metrics bytes.Buffer
+	prom.WriteTo(&metrics)
+	for _, line := range []string{
+		`bitmark_sdk_requests_in_flight{operation="getTx"} 0`,
+		`bitmark_sdk_requests_total{operation="getTx",status="200"} 1`,
+		`bitmark_sdk_request_errors_total{operation="queryBitmarks",code="1001"} 1`,
+		`bitmark_sdk_request_duration_seconds_count{operation="queryBitmarks"} 1`,
+		`bitmark_sdk_request_duration_seconds_bucket{operation="getTx",le="+Inf"} 1`,
+	} {
+		if !strings.Contains(metrics.String(), line+"\n") {
+			t.Errorf("metric %s not found in:\n%s", line, metrics.String())
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package bitmarksdk
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// EndpointClass groups the API operations sharing a rate limit.
+type EndpointClass string
+
+const (
+	EndpointIssue     EndpointClass = "issue"
+	EndpointTransfer  EndpointClass = "transfer"
+	EndpointSession   EndpointClass = "session"
+	EndpointQuery     EndpointClass = "query"
+	EndpointKeyLookup EndpointClass = "key_lookup"
+)
+
+var operationClasses = map[string]EndpointClass{
+	"uploadAsset":           EndpointIssue,
+	"issue":                 EndpointIssue,
+	"transfer":              EndpointTransfer,
+	"countersignTransfer":   EndpointTransfer,
+	"submitTransferOffer":   EndpointTransfer,
+	"completeTransferOffer": EndpointTransfer,
+	"addSessionData":        EndpointSession,
+	"registerEncPubkey":     EndpointSession,
+	"updateLease":           EndpointSession,
+	"revokeLease":           EndpointSession,
+	"getEncPubkey":          EndpointKeyLookup,
+}
+
+// endpointClass returns the class of the operation, which is a query if not listed
+func endpointClass(op string) EndpointClass {
+	if class, ok := operationClasses[op]; ok {
+		return class
+	}
+	return EndpointQuery
+}
+
+// RateLimit is the limit of an endpoint class. Rate is the number of requests
+// per second, with bursts of up to Burst requests. A zero Rate does not limit
+// the rate, and a zero MaxConcurrent does not cap the requests in flight.
+type RateLimit struct {
+	Rate          float64
+	Burst         int
+	MaxConcurrent int
+}
+
+const (
+	defaultRetryAfter       = time.Second
+	defaultRateLimitRetries = 3
+)
+
+// RateLimiter limits the API requests by endpoint class with token buckets.
+// On a 429 response, the class is paused until the Retry-After of the response,
+// its rate is halved and recovers gradually, and the request is retried up to
+// MaxRetries times. A RateLimiter can be shared by the clients of a process.
+type RateLimiter struct {
+	MaxRetries int
+
+	limits  map[EndpointClass]RateLimit
+	mu      sync.Mutex
+	buckets map[EndpointClass]*bucket
+}
+
+// NewRateLimiter limits the classes by the limits. The classes not listed are only
+// paused on 429 responses.
+func NewRateLimiter(limits map[EndpointClass]RateLimit) *RateLimiter {
+	l := &RateLimiter{
+		MaxRetries: defaultRateLimitRetries,
+		limits:     make(map[EndpointClass]RateLimit),
+		buckets:    make(map[EndpointClass]*bucket),
+	}
+	for class, limit := range limits {
+		l.limits[class] = limit
+	}
+	return l
+}
+
+func (l *RateLimiter) bucket(class EndpointClass) *bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[class]
+	if !ok {
+		b = newBucket(l.limits[class])
+		l.buckets[class] = b
+	}
+	return b
+}
+
+// acquire waits for a token and a slot of the class. The slot is freed by the returned func.
+func (l *RateLimiter) acquire(ctx context.Context, class EndpointClass) (func(), error) {
+	b := l.bucket(class)
+
+	release := func() {}
+	if b.slots != nil {
+		select {
+		case b.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		release = func() { <-b.slots }
+	}
+
+	if delay := b.reserve(time.Now()); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// observe adapts the class to the response status
+func (l *RateLimiter) observe(class EndpointClass, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	b := l.bucket(class)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		b.throttle(time.Now(), retryAfter(resp.Header.Get("Retry-After")))
+	} else {
+		b.restore()
+	}
+}
+
+// retryAfter parses the Retry-After header in seconds or as an HTTP date
+func retryAfter(value string) time.Duration {
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return defaultRetryAfter
+}
+
+type bucket struct {
+	sync.Mutex
+	limit       RateLimit
+	rate        float64 // the limit rate adapted to the throttling
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	slots       chan struct{}
+}
+
+func newBucket(limit RateLimit) *bucket {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+
+	b := &bucket{limit: limit, rate: limit.Rate, tokens: float64(limit.Burst)}
+	if limit.MaxConcurrent > 0 {
+		b.slots = make(chan struct{}, limit.MaxConcurrent)
+	}
+	return b
+}
+
+// reserve takes a token, and returns how long to wait before using it
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.Lock()
+	defer b.Unlock()
+
+	var delay time.Duration
+	if b.rate > 0 {
+		if !b.last.IsZero() {
+			b.tokens += now.Sub(b.last).Seconds() * b.rate
+			if b.tokens > float64(b.limit.Burst) {
+				b.tokens = float64(b.limit.Burst)
+			}
+		}
+		b.last = now
+
+		b.tokens--
+		if b.tokens < 0 {
+			delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+		}
+	}
+
+	if paused := b.pausedUntil.Sub(now); paused > delay {
+		delay = paused
+	}
+	return delay
+}
+
+func (b *bucket) throttle(now time.Time, wait time.Duration) {
+	b.Lock()
+	defer b.Unlock()
+
+	if until := now.Add(wait); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+	if b.rate > b.limit.Rate/16 {
+		b.rate /= 2
+	}
+	b.tokens = 0
+}
+
+// restore raises the adapted rate back to the limit by a tenth per success
+func (b *bucket) restore() {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.rate < b.limit.Rate {
+		b.rate += b.limit.Rate / 10
+		if b.rate > b.limit.Rate {
+			b.rate = b.limit.Rate
+		}
+	}
+}
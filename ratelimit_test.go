@@ -0,0 +1,169 @@
+package bitmarksdk
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	b := newBucket(RateLimit{Rate: 10, Burst: 2})
+	now := time.Now()
+
+	if d := b.reserve(now); d != 0 {
+		t.Fatalf("unexpected delay of the burst: %s", d)
+	}
+	if d := b.reserve(now); d != 0 {
+		t.Fatalf("unexpected delay of the burst: %s", d)
+	}
+	if d := b.reserve(now); d != 100*time.Millisecond {
+		t.Fatalf("unexpected delay after the burst: %s", d)
+	}
+	if d := b.reserve(now.Add(time.Second)); d != 0 {
+		t.Fatalf("unexpected delay after refill: %s", d)
+	}
+
+	b.throttle(now, 3*time.Second)
+	if b.rate != 5 {
+		t.Fatalf("rate not halved: %f", b.rate)
+	}
+	if d := b.reserve(now.Add(time.Second)); d != 2*time.Second {
+		t.Fatalf("unexpected delay while paused: %s", d)
+	}
+	b.restore()
+	if b.rate != 6 {
+		t.Fatalf("rate not restored: %f", b.rate)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d := retryAfter("2"); d != 2*time.Second {
+		t.Fatalf("unexpected duration: %s", d)
+	}
+	if d := retryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); d < 58*time.Second || d > time.Minute {
+		t.Fatalf("unexpected duration: %s", d)
+	}
+	if d := retryAfter(""); d != defaultRetryAfter {
+		t.Fatalf("unexpected duration: %s", d)
+	}
+}
+
+func TestRateLimiterConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		writeJSON(w, map[string]*Tx{"tx": {Status: TxConfirmed}})
+	})
+
+	limiter := NewRateLimiter(map[EndpointClass]RateLimit{EndpointQuery: {MaxConcurrent: 2}})
+	client1, ts := newTestClient(handler, &Config{RateLimiter: limiter})
+	defer ts.Close()
+	client2, ts2 := newTestClient(handler, &Config{RateLimiter: limiter})
+	defer ts2.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(c *Client) {
+			defer wg.Done()
+			if _, err := c.GetTx("tx"); err != nil {
+				t.Error(err)
+			}
+		}([]*Client{client1, client2}[i%2])
+	}
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Fatalf("%d requests in flight", maxInFlight)
+	}
+}
+
+func TestRateLimiterRetry(t *testing.T) {
+	var calls int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			writeJSON(w, &ServiceError{Code: 429, Message: "too many requests"})
+			return
+		}
+		writeJSON(w, map[string][]string{"bitmarkIds": {"bid"}})
+	})
+
+	rec := &recordingObserver{}
+	limiter := NewRateLimiter(nil)
+	client, ts := newTestClient(handler, &Config{RateLimiter: limiter, Observers: []Observer{rec}})
+	defer ts.Close()
+
+	if _, err := client.service.submitRequest("issue", mustRequest(client, "POST", "/v1/issue", `{"issues":[]}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || rec.finished[0].Retries != 2 {
+		t.Fatalf("unexpected retries: %d calls, %d retries", calls, rec.finished[0].Retries)
+	}
+
+	limiter.MaxRetries = 0
+	atomic.StoreInt32(&calls, 0)
+	_, err := client.service.submitRequest("issue", mustRequest(client, "POST", "/v1/issue", "{}"), nil)
+	if se, ok := err.(*ServiceError); !ok || se.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", client.service.apiEndpoint+"/v1/txs/tx", nil)
+	limiter.bucket(EndpointQuery).throttle(time.Now(), time.Minute)
+	if _, err := client.service.submitRequest("getTx", req, nil); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRateLimiterRetrySignedRequest(t *testing.T) {
+	verifier := NewRequestVerifier(time.Minute)
+	var calls int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := verifier.Verify(r, "revokeLease", "bid"); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeJSON(w, &ServiceError{Code: 401, Message: err.Error()})
+			return
+		}
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			writeJSON(w, &ServiceError{Code: 429, Message: "too many requests"})
+			return
+		}
+	})
+
+	client, ts := newTestClient(handler, &Config{RateLimiter: NewRateLimiter(nil)})
+	defer ts.Close()
+
+	acct := newTestAccount(Testnet)
+	req, _ := client.service.newSignedAPIRequest("DELETE", "/v2/leases/bid", nil, acct, "revokeLease", "bid")
+	if _, err := client.service.submitRequest("revokeLease", req, nil); err != nil {
+		t.Fatalf("retry not signed again: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("unexpected calls: %d", calls)
+	}
+}
+
+func mustRequest(client *Client, method, path, body string) *http.Request {
+	req, err := client.service.newAPIRequest(method, path, strings.NewReader(body))
+	if err != nil {
+		panic(err)
+	}
+	return req
+}
@@ -4,11 +4,10 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"strings"
 	"sync/atomic"
 	"time"
-	"unicode/utf8"
 
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/sha3"
 )
 
@@ -29,8 +28,9 @@ const (
 
 // TODO: refine errors
 var (
-	ErrInvalidLength  = errors.New("invalid length")
-	ErrInvalidAccount = errors.New("invalid account")
+	ErrInvalidLength    = errors.New("invalid length")
+	ErrInvalidAccount   = errors.New("invalid account")
+	ErrInvalidSignature = errors.New("invalid signature")
 )
 
 var nonceIndex uint64
@@ -44,26 +44,10 @@ type AssetRecord struct {
 }
 
 func NewAssetRecord(name, fingerprint string, metadata map[string]string, registrant *Account) (*AssetRecord, error) {
-	parts := make([]string, 0, len(metadata)*2)
-	for key, val := range metadata {
-		if key == "" || val == "" {
-			continue
-		}
-		parts = append(parts, key, val)
-	}
-	compactMetadata := strings.Join(parts, "\u0000")
-
-	if utf8.RuneCountInString(name) < minNameLength || utf8.RuneCountInString(name) > maxNameLength {
-		return nil, errors.New("property name not set or exceeds the maximum length (64 Unicode characters)")
-	}
-
-	if utf8.RuneCountInString(fingerprint) < minFingerprintLength || utf8.RuneCountInString(fingerprint) > maxFingerprintLength {
-		return nil, errors.New("property fingerprint not set or exceeds the maximum length (1024 Unicode characters)")
-	}
-
-	if utf8.RuneCountInString(compactMetadata) > maxMetadataLength {
-		return nil, errors.New("property metadata exceeds the maximum length (1024 Unicode characters)")
+	if err := ValidateAssetProperties(name, fingerprint, metadata); err != nil {
+		return nil, err
 	}
+	compact := compactMetadata(metadata)
 
 	if registrant == nil {
 		return nil, errors.New("registrant not set")
@@ -73,11 +57,11 @@ func NewAssetRecord(name, fingerprint string, metadata map[string]string, regist
 	message := toVarint64(assetTag)
 	message = appendString(message, name)
 	message = appendString(message, fingerprint)
-	message = appendString(message, compactMetadata)
+	message = appendString(message, compact)
 	message = appendBytes(message, registrant.bytes())
 	signature := hex.EncodeToString(registrant.AuthKey.Sign(message))
 
-	return &AssetRecord{name, fingerprint, compactMetadata, registrant.AccountNumber(), signature}, nil
+	return &AssetRecord{name, fingerprint, compact, registrant.AccountNumber(), signature}, nil
 }
 
 type IssueRecord struct {
@@ -154,7 +138,10 @@ func (i *IssueRecord) Id() (string, error) {
 	// pack and sign
 	message := toVarint64(issueTag)
 	message = appendBytes(message, assetIndex)
-	message = appendAccount(message, i.Owner)
+	message, err = appendAccount(message, i.Owner)
+	if err != nil {
+		return "", err
+	}
 	message = appendUint64(message, i.Nonce)
 	message = appendBytes(message, sig)
 
@@ -211,7 +198,10 @@ func NewTransferRecord(txId string, receiver string, owner *Account) (*TransferR
 	message := toVarint64(transferUnratifiedTag)
 	message = appendBytes(message, link)
 	message = append(message, 0) // payment not supported
-	message = appendAccount(message, receiver)
+	message, err = appendAccount(message, receiver)
+	if err != nil {
+		return nil, err
+	}
 	signature := hex.EncodeToString(owner.AuthKey.Sign(message))
 
 	return &TransferRecord{txId, receiver, signature}, nil
@@ -250,15 +240,56 @@ func NewTransferOffer(bitmark *Bitmark, txId, receiver string, sender *Account)
 	}
 
 	// pack and sign
+	message, err := packTransferOffer(link, receiver)
+	if err != nil {
+		return nil, err
+	}
+	signature := hex.EncodeToString(sender.AuthKey.Sign(message))
+	return &TransferOfferRecord{bitmark, txId, receiver, signature}, nil
+}
+
+// VerifySignature checks that the offer is signed by the sender.
+func (t *TransferOfferRecord) VerifySignature(sender string) error {
+	link, err := hex.DecodeString(t.Link)
+	if err != nil || len(link) != merkleDigestLength {
+		return ErrInvalidLength
+	}
+
+	sig, err := hex.DecodeString(t.Signature)
+	if err != nil {
+		return ErrInvalidLength
+	}
+
+	signer, err := ParseAccountNumber(sender)
+	if err != nil {
+		return err
+	}
+
+	message, err := packTransferOffer(link, t.Owner)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(signer.PublicKey, message, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func packTransferOffer(link []byte, receiver string) ([]byte, error) {
 	message := toVarint64(transferCountersignedTag)
 	message = appendBytes(message, link)
 	message = append(message, 0) // payment not supported
-	message = appendAccount(message, receiver)
-	signature := hex.EncodeToString(sender.AuthKey.Sign(message))
-	return &TransferOfferRecord{bitmark, txId, receiver, signature}, nil
+	return appendAccount(message, receiver)
 }
 
-func (t *TransferOfferRecord) Countersign(receiver *Account) (*CountersignedTransferRecord, error) {
+// countersign verifies the offer is signed by the sender, and countersigns it by the receiver.
+// The sender must be checked against the registry by Client.inspectTransferOffer.
+func (t *TransferOfferRecord) countersign(receiver *Account, sender string) (*CountersignedTransferRecord, error) {
+	if err := t.VerifySignature(sender); err != nil {
+		return nil, err
+	}
+
 	link, err := hex.DecodeString(t.Link)
 	if err != nil || len(link) != merkleDigestLength {
 		return nil, ErrInvalidLength
@@ -303,7 +334,10 @@ func (ct *CountersignedTransferRecord) Id() (string, error) {
 	message := toVarint64(transferCountersignedTag)
 	message = appendBytes(message, link)
 	message = append(message, 0) // payment not supported
-	message = appendAccount(message, ct.Owner)
+	message, err = appendAccount(message, ct.Owner)
+	if err != nil {
+		return "", err
+	}
 	message = appendBytes(message, sig)
 	message = appendBytes(message, countersig)
 
@@ -319,9 +353,13 @@ func appendString(buffer []byte, s string) []byte {
 	return append(buffer, s...)
 }
 
-func appendAccount(buffer []byte, acctNo string) []byte {
-	acctBytes := fromBase58(acctNo)
-	return appendBytes(buffer, acctBytes[:len(acctBytes)-checksumLength])
+// appendAccount appends the account number, which is rejected if invalid
+func appendAccount(buffer []byte, acctNo string) ([]byte, error) {
+	acct, err := ParseAccountNumber(acctNo)
+	if err != nil {
+		return nil, err
+	}
+	return appendBytes(buffer, acct.bytes()), nil
 }
 
 func appendBytes(buffer []byte, data []byte) []byte {
@@ -362,7 +400,7 @@ type Bitmark struct {
 	Issuer      string       `json:"issuer"`
 	IssuedAt    time.Time    `json:"issued_at"`
 	Head        string       `json:"head"`
-	Status      string       `json:"status"`
+	Status      TxStatus     `json:"status"`
 	BlockNumber uint         `json:"block_number"`
 	Offset      uint         `json:"offset"`
 	CreatedAt   time.Time    `json:"created_at"`
@@ -372,9 +410,9 @@ type Bitmark struct {
 }
 
 type Provenance struct {
-	TxId   string `json:"tx_id"`
-	Owner  string `json:"owner"`
-	Status string `json:"status"`
+	TxId   string   `json:"tx_id"`
+	Owner  string   `json:"owner"`
+	Status TxStatus `json:"status"`
 }
 
 type Asset struct {
@@ -383,7 +421,7 @@ type Asset struct {
 	Fingerprint string            `json:"fingerprint"`
 	Metadata    map[string]string `json:"metadata"`
 	Registrant  string            `json:"registrant"`
-	Status      string            `json:"status"`
+	Status      TxStatus          `json:"status"`
 	BlockNumber int               `json:"block_number"`
 	BlockOffset int               `json:"block_offset"`
 	ExpiresAt   string            `json:"expires_at"`
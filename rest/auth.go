@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiKeyContextKey  = "api_key"
+	apiKeyPlaceholder = "replace-with-a-random-secret" // the key of the sample configuration
+)
+
+type apiKeyConfig struct {
+	Key      string   `hcl:"key"`
+	Accounts []string `hcl:"accounts"`
+}
+
+// validateAPIKeys rejects the empty keys and the key of the sample configuration
+func validateAPIKeys(keys map[string]apiKeyConfig) error {
+	for name, k := range keys {
+		if k.Key == "" {
+			return fmt.Errorf("API key %s: key not set", name)
+		}
+		if k.Key == apiKeyPlaceholder {
+			return fmt.Errorf("API key %s: key of the sample configuration", name)
+		}
+	}
+	return nil
+}
+
+// authenticate rejects the requests without a configured API key. The key is read from
+// the X-API-Key header or from a bearer token.
+func authenticate(keys map[string]apiKeyConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			key = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+
+		for name, k := range keys {
+			if key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(k.Key)) == 1 {
+				c.Set(apiKeyContextKey, name)
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse{errorBody{Kind: kindUnauthorized, Message: "invalid API key"}})
+	}
+}
+
+// allowedAccounts returns the handles the API key of the request may use,
+// and whether it may use every account in the vault
+func allowedAccounts(c *gin.Context) ([]string, bool) {
+	name := c.GetString(apiKeyContextKey)
+
+	handles := cfg.APIKeys[name].Accounts
+	for _, h := range handles {
+		if h == vaultAllAccounts {
+			return nil, true
+		}
+	}
+	return append(handles, accounts.granted(name)...), false
+}
+
+func isAllowedAccount(c *gin.Context, handle string) bool {
+	handles, all := allowedAccounts(c)
+	if all {
+		return true
+	}
+	for _, h := range handles {
+		if h == handle {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateAPIKeys(t *testing.T) {
+	if err := validateAPIKeys(map[string]apiKeyConfig{"ops": {Key: "0f3c9a7d"}}); err != nil {
+		t.Error(err)
+	}
+
+	for _, key := range []string{"", apiKeyPlaceholder} {
+		keys := map[string]apiKeyConfig{"ops": {Key: "0f3c9a7d"}, "example": {Key: key}}
+		if err := validateAPIKeys(keys); err == nil {
+			t.Errorf("key %q accepted", key)
+		}
+	}
+}
+
+func serveTestRequest(r *gin.Engine, method, path, key string, body interface{}) *httptest.ResponseRecorder {
+	var dat []byte
+	if body != nil {
+		dat, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(dat))
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	v, _, cleanup := newTestVault(t)
+	defer cleanup()
+	accounts = v
+
+	cfg = &config{APIKeys: map[string]apiKeyConfig{"ops": {Key: "0f3c9a7d"}}}
+	r := newRouter()
+
+	if w := serveTestRequest(r, "GET", "/accounts", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("no key: status %d", w.Code)
+	}
+	if w := serveTestRequest(r, "GET", "/accounts", "0f3c9a7e", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong key: status %d", w.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/accounts", nil)
+	req.Header.Set("Authorization", "Bearer 0f3c9a7d")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("bearer token: status %d", w.Code)
+	}
+
+	// the document is public
+	if w := serveTestRequest(r, "GET", "/openapi.json", "", nil); w.Code != http.StatusOK {
+		t.Errorf("openapi.json: status %d", w.Code)
+	}
+}
+
+func TestAccountScoping(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	v, _, cleanup := newTestVault(t)
+	defer cleanup()
+	accounts = v
+
+	acct, err := client.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configured, err := v.store(acct, "setup")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg = &config{APIKeys: map[string]apiKeyConfig{
+		"ops":   {Key: "0f3c9a7d", Accounts: []string{configured}},
+		"other": {Key: "7b1e4d20"},
+		"audit": {Key: "c95a0e31", Accounts: []string{vaultAllAccounts}},
+	}}
+	r := newRouter()
+
+	w := serveTestRequest(r, "POST", "/account", "0f3c9a7d", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create account: status %d: %s", w.Code, w.Body)
+	}
+	var created accountResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	list := func(key string) map[string]bool {
+		w := serveTestRequest(r, "GET", "/accounts", key, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("list accounts: status %d", w.Code)
+		}
+		var resp listAccountsResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+
+		handles := make(map[string]bool)
+		for _, a := range resp.Accounts {
+			handles[a.Account] = true
+		}
+		return handles
+	}
+
+	if handles := list("0f3c9a7d"); len(handles) != 2 || !handles[configured] || !handles[created.Account] {
+		t.Errorf("ops accounts: %v", handles)
+	}
+	if handles := list("7b1e4d20"); len(handles) != 0 {
+		t.Errorf("other accounts: %v", handles)
+	}
+	if handles := list("c95a0e31"); len(handles) != 2 {
+		t.Errorf("audit accounts: %v", handles)
+	}
+
+	// an account of another key is forbidden before it is opened
+	w = serveTestRequest(r, "POST", "/leases/list", "7b1e4d20", accountRequest{created.Account})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("other key: status %d", w.Code)
+	}
+	w = serveTestRequest(r, "POST", "/leases/list", "c95a0e31", accountRequest{"acct_5f1c0c4c2e8a4d6b9d3e7a1f0b2c4d6e"})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown account: status %d", w.Code)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	kindInvalidRequest = "invalid_request"
+	kindService        = "service"
+	kindIntegrity      = "integrity"
+	kindLeaseExpired   = "lease_expired"
+	kindOfferRejected  = "offer_rejected"
+	kindNotFound       = "not_found"
+	kindUnauthorized   = "unauthorized"
+	kindForbidden      = "forbidden"
+	kindInternal       = "internal"
+)
+
+type errorDetail struct {
+	Field   string `json:"field"`
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+}
+
+type errorBody struct {
+	Kind    string        `json:"kind"`
+	Code    int           `json:"code,omitempty"`
+	Message string        `json:"message"`
+	Details []errorDetail `json:"details,omitempty"`
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+func abortWithBadRequest(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, errorResponse{errorBody{Kind: kindInvalidRequest, Message: message}})
+}
+
+func abortWithNotFound(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusNotFound, errorResponse{errorBody{Kind: kindNotFound, Message: message}})
+}
+
+func abortWithForbidden(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, errorResponse{errorBody{Kind: kindForbidden, Message: message}})
+}
+
+// abortWithError responds the error of an SDK operation. The code of
+// the Bitmark API error is passed through.
+func abortWithError(c *gin.Context, err error) {
+	status, body := classifyError(err)
+	if status == http.StatusInternalServerError {
+		log.Errorf("%s %s: %s", c.Request.Method, c.Request.URL.Path, err)
+	}
+	c.AbortWithStatusJSON(status, errorResponse{body})
+}
+
+func classifyError(err error) (int, errorBody) {
+	body := errorBody{Kind: kindInternal, Message: err.Error()}
+
+	var serviceErr *sdk.ServiceError
+	var propertyErrs sdk.AssetPropertyErrors
+	var integrityErr *sdk.IntegrityError
+	var leaseErr *sdk.LeaseExpiredError
+	var leaseNotFoundErr *sdk.LeaseNotFoundError
+
+	switch {
+	case errors.As(err, &serviceErr):
+		body.Kind = kindService
+		body.Code = serviceErr.Code
+		body.Message = serviceErr.Message
+		return http.StatusBadGateway, body
+	case errors.As(err, &propertyErrs):
+		body.Kind = kindInvalidRequest
+		for _, e := range propertyErrs {
+			body.Details = append(body.Details, errorDetail{string(e.Field), e.Key, e.Err.Error()})
+		}
+		return http.StatusBadRequest, body
+	case errors.As(err, &integrityErr):
+		body.Kind = kindIntegrity
+		return http.StatusBadGateway, body
+	case errors.As(err, &leaseErr):
+		body.Kind = kindLeaseExpired
+		return http.StatusForbidden, body
+	case errors.As(err, &leaseNotFoundErr):
+		body.Kind = kindNotFound
+		return http.StatusNotFound, body
+	case isOfferError(err):
+		body.Kind = kindOfferRejected
+		return http.StatusConflict, body
+	case isInvalidArgument(err):
+		body.Kind = kindInvalidRequest
+		return http.StatusBadRequest, body
+	}
+	return http.StatusInternalServerError, body
+}
+
+func isOfferError(err error) bool {
+	for _, target := range []error{
+		sdk.ErrOfferBitmarkUnknown,
+		sdk.ErrOfferSenderNotOwner,
+		sdk.ErrOfferStaleLink,
+		sdk.ErrOfferBitmarkMismatch,
+		sdk.ErrOfferBitmarkPending,
+		sdk.ErrInvalidSignature,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func isInvalidArgument(err error) bool {
+	for _, target := range []error{
+		sdk.ErrNetworkMismatch,
+		sdk.ErrAccountNumberSizeMismatch,
+		sdk.ErrAccountNumberChecksumMismatch,
+		sdk.ErrAccountNumberUnknownAlgorithm,
+		sdk.ErrSeedSizeMismatch,
+		sdk.ErrSeedHeaderMismatch,
+		sdk.ErrSeedChecksumMismatch,
+		sdk.ErrInvalidLength,
+		sdk.ErrInvalidAccount,
+		sdk.ErrUnknownFingerprintScheme,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
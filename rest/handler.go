@@ -1,120 +1,771 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 
 	sdk "github.com/bitmark-inc/bitmark-sdk-go"
 	"github.com/gin-gonic/gin"
 )
 
+type accountRequest struct {
+	Account string `json:"account" binding:"required" example:"acct_5f1c0c4c2e8a4d6b9d3e7a1f0b2c4d6e"`
+}
+
+func (r *accountRequest) handle() string {
+	return r.Account
+}
+
+type accountScopedRequest interface {
+	handle() string
+}
+
+// bindAccountRequest binds the JSON body and opens the vault account of the request
+func bindAccountRequest(c *gin.Context, req accountScopedRequest) (*sdk.Account, bool) {
+	if err := c.ShouldBindJSON(req); err != nil {
+		abortWithBadRequest(c, "invalid request body: "+err.Error())
+		return nil, false
+	}
+
+	if !isAllowedAccount(c, req.handle()) {
+		abortWithForbidden(c, "account not allowed for the API key")
+		return nil, false
+	}
+
+	acct, err := accounts.open(req.handle())
+	if err == errVaultAccountNotFound {
+		abortWithNotFound(c, err.Error())
+		return nil, false
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return nil, false
+	}
+	return acct, true
+}
+
+type accountResponse struct {
+	Account        string   `json:"account"`
+	AccountNumber  string   `json:"account_number"`
+	RecoveryPhrase []string `json:"recovery_phrase,omitempty"`
+}
+
+// handleCreateAccount stores a new account in the vault and grants it to the API key.
+// The recovery phrase is returned only here, for an offline backup.
 func handleCreateAccount() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		acct, _ := sdk.NewAccount(netork)
+		acct, err := client.CreateAccount()
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"account_number":  acct.AccountNumber(),
-			"seed":            acct.Seed(),
-			"recovery_phrase": acct.RecoveryPhrase(),
-		})
+		handle, err := accounts.store(acct, c.GetString(apiKeyContextKey))
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, accountResponse{handle, acct.AccountNumber(), acct.RecoveryPhrase()})
+	}
+}
+
+type listAccountsResponse struct {
+	Accounts []accountResponse `json:"accounts"`
+}
+
+func handleListAccounts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handles, all := allowedAccounts(c)
+		if all {
+			var err error
+			if handles, err = accounts.handles(); err != nil {
+				abortWithError(c, err)
+				return
+			}
+		}
+
+		resp := listAccountsResponse{Accounts: make([]accountResponse, 0, len(handles))}
+		for _, h := range handles {
+			r, err := accounts.record(h)
+			if err == errVaultAccountNotFound {
+				continue
+			}
+			if err != nil {
+				abortWithError(c, err)
+				return
+			}
+			resp.Accounts = append(resp.Accounts, accountResponse{Account: h, AccountNumber: r.AccountNumber})
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+type assetProperties struct {
+	Name     string            `json:"property_name"`
+	Metadata map[string]string `json:"property_metadata"`
+}
+
+func (p assetProperties) info() *sdk.AssetInfo {
+	if p.Name == "" {
+		return nil
 	}
+	return &sdk.AssetInfo{Name: p.Name, Metadata: p.Metadata}
+}
+
+type registerAssetRequest struct {
+	accountRequest
+	assetProperties
+	Fingerprint string `json:"fingerprint" binding:"required" example:"01840006653e9ac9e95117a15c915caab81662918e925de9e004f774ff82d7079a40d4d27b1b372657c61d46d470304c88c788b3a4527ad074d1dccbee5dbaa99a"`
+}
+
+type registerAssetResponse struct {
+	AssetId string `json:"asset_id"`
+}
+
+func handleRegisterAsset() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req registerAssetRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+
+		assetId, err := client.RegisterAsset(acct, req.Fingerprint, &sdk.AssetInfo{Name: req.Name, Metadata: req.Metadata})
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, registerAssetResponse{assetId})
+	}
+}
+
+type issueResponse struct {
+	AssetId    string   `json:"asset_id"`
+	BitmarkIds []string `json:"bitmark_ids"`
 }
 
 type issueRequest struct {
-	Seed          string            `json:"seed"`
-	FilePath      string            `json:"file_path"`
-	Accessibility sdk.Accessibility `json:"accessibility"`
-	Name          string            `json:"property_name"`
-	Metadata      map[string]string `json:"property_metadata"`
-	Quantity      int               `json:"quantity"`
+	accountRequest
+	assetProperties
+	FilePath      string            `json:"file_path" binding:"required" example:"contracts/2018.pdf"`
+	Accessibility sdk.Accessibility `json:"accessibility" binding:"required,oneof=public private"`
+	Quantity      int               `json:"quantity" binding:"min=1" example:"10"`
 }
 
 func handleIssue() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req issueRequest
-		if err := c.BindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid request body"})
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+
+		path, err := resolveUpload(req.FilePath)
+		if err != nil {
+			abortWithForbidden(c, err.Error())
 			return
 		}
 
-		acct, err := sdk.AccountFromSeed(req.Seed)
+		af, err := sdk.NewAssetFileFromPath(path, req.Accessibility)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid seed"})
+			abortWithBadRequest(c, "unable to read the asset file: "+err.Error())
 			return
 		}
 
-		assetId, bitmarkIds, err := acct.IssueNewBitmarks(req.FilePath, req.Accessibility, req.Name, req.Metadata, req.Quantity)
+		bitmarkIds, err := client.IssueByAssetFile(acct, af, req.Quantity, req.info())
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid seed"})
+			abortWithError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"asset_id":    assetId,
-			"bitmark_ids": bitmarkIds,
-		})
+		c.JSON(http.StatusOK, issueResponse{af.Id(), bitmarkIds})
+	}
+}
+
+// resolveUpload returns the path of the asset file under the upload directory.
+// Absolute paths, parent references and symbolic links are rejected, so a caller
+// cannot read the other files of the server.
+func resolveUpload(name string) (string, error) {
+	if cfg.UploadDir == "" {
+		return "", errors.New("upload directory not configured")
+	}
+
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", errors.New("file path outside the upload directory")
+	}
+
+	dir, err := filepath.EvalSymlinks(cfg.UploadDir)
+	if err != nil {
+		return "", errors.New("upload directory not accessible")
+	}
+	path := filepath.Join(dir, clean)
+
+	// a resolved path differing from the joined one went through a symbolic link
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", errors.New("file not found in the upload directory")
+	}
+	if resolved != path {
+		return "", errors.New("symbolic links are not allowed")
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", errors.New("file not found in the upload directory")
+	}
+	return resolved, nil
+}
+
+type issueByAssetIdRequest struct {
+	accountRequest
+	AssetId  string `json:"asset_id" binding:"required" example:"0e0b4e3bd771811d35a23707ba6197aa1dd5937439a221eaf8e7909309e7b31b6c0e06a1001c261a099abf04c560199db898bc154cf128aa9efa5efd36030c64"`
+	Quantity int    `json:"quantity" binding:"min=1" example:"10"`
+}
+
+func handleIssueByAssetId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req issueByAssetIdRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+
+		bitmarkIds, err := client.IssueByAssetId(acct, req.AssetId, req.Quantity)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, issueResponse{req.AssetId, bitmarkIds})
+	}
+}
+
+type issueByFingerprintRequest struct {
+	accountRequest
+	assetProperties
+	Fingerprint string `json:"fingerprint" binding:"required" example:"01840006653e9ac9e95117a15c915caab81662918e925de9e004f774ff82d7079a40d4d27b1b372657c61d46d470304c88c788b3a4527ad074d1dccbee5dbaa99a"`
+	Quantity    int    `json:"quantity" binding:"min=1" example:"10"`
+}
+
+func handleIssueByFingerprint() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req issueByFingerprintRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+
+		bitmarkIds, err := client.IssueByFingerprint(acct, req.Fingerprint, req.Quantity, req.info())
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, issueResponse{sdk.AssetIdFromFingerprint(req.Fingerprint), bitmarkIds})
 	}
 }
 
 type transferRequest struct {
-	Seed      string `json:"seed"`
-	BitmarkId string `json:"bitmark_id"`
-	Receiver  string `json:"receiver"`
+	accountRequest
+	BitmarkId string `json:"bitmark_id" binding:"required" example:"f1b5a3c5c6ac6f2ec21bb7b3ed3ebeb1a16cd6e1a1d0c2ba9e3b7e57e5f1c1a9"`
+	Receiver  string `json:"receiver" binding:"required" example:"eZpG6Wi9SQvpDatEP7QGrx6nvzwd6s6R8DgMKgDbDY1R5bjzb9"`
+}
+
+type txResponse struct {
+	TxId string `json:"tx_id"`
 }
 
 func handleTransfer() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req transferRequest
-		if err := c.BindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid request body"})
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+
+		txId, err := client.Transfer(acct, req.BitmarkId, req.Receiver)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, txResponse{txId})
+	}
+}
+
+type countersignRequest struct {
+	accountRequest
+	Offer *sdk.TransferOfferRecord `json:"offer" binding:"required"`
+}
+
+func handleCountersignTransfer() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req countersignRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+
+		txId, err := client.CountersignTransfer(acct, req.Offer)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, txResponse{txId})
+	}
+}
+
+type createOfferRequest struct {
+	accountRequest
+	BitmarkId string      `json:"bitmark_id" binding:"required" example:"f1b5a3c5c6ac6f2ec21bb7b3ed3ebeb1a16cd6e1a1d0c2ba9e3b7e57e5f1c1a9"`
+	Receiver  string      `json:"receiver" binding:"required" example:"eZpG6Wi9SQvpDatEP7QGrx6nvzwd6s6R8DgMKgDbDY1R5bjzb9"`
+	ExtraInfo interface{} `json:"extra_info"`
+	Submit    bool        `json:"submit"`
+}
+
+type createOfferResponse struct {
+	OfferId string                   `json:"offer_id,omitempty"`
+	Offer   *sdk.TransferOfferRecord `json:"offer"`
+}
+
+// handleCreateOffer signs a transfer offer by the sender, and submits it if requested
+func handleCreateOffer() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req createOfferRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+
+		offer, err := client.SignTransferOffer(acct, req.BitmarkId, req.Receiver, true)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		resp := createOfferResponse{Offer: offer}
+		if req.Submit {
+			resp.OfferId, err = client.SubmitTransferOffer(acct, offer, req.ExtraInfo)
+			if err != nil {
+				abortWithError(c, err)
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+type listOffersRequest struct {
+	accountRequest
+	Direction sdk.TransferOfferDirection `json:"direction" binding:"required,oneof=incoming outgoing"`
+	Status    sdk.TransferOfferStatus    `json:"status"`
+}
+
+type listOffersResponse struct {
+	Offers []*sdk.TransferOffer `json:"offers"`
+}
+
+func handleListOffers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req listOffersRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
 			return
 		}
 
-		acct, err := sdk.AccountFromSeed(req.Seed)
+		offers, err := client.ListTransferOffers(acct, req.Direction, req.Status)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid seed"})
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, listOffersResponse{offers})
+	}
+}
+
+func handleCompleteOffer(action sdk.TransferOfferAction) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req accountRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
 			return
 		}
 
-		txId, err := acct.TransferBitmark(req.BitmarkId, req.Receiver)
+		offerId := c.Param("id")
+		var txId string
+		var err error
+		switch action {
+		case sdk.ActionAccept:
+			txId, err = client.AcceptTransferOffer(acct, offerId)
+		case sdk.ActionReject:
+			err = client.RejectTransferOffer(acct, offerId)
+		case sdk.ActionCancel:
+			err = client.CancelTransferOffer(acct, offerId)
+		}
 		if err != nil {
-			log.Error(err.Error())
-			c.JSON(http.StatusBadRequest, gin.H{"message": err})
+			abortWithError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"txId": txId,
-		})
+		c.JSON(http.StatusOK, txResponse{txId})
+	}
+}
+
+type rentRequest struct {
+	accountRequest
+	BitmarkId string   `json:"bitmark_id" binding:"required" example:"f1b5a3c5c6ac6f2ec21bb7b3ed3ebeb1a16cd6e1a1d0c2ba9e3b7e57e5f1c1a9"`
+	Renters   []string `json:"renters" binding:"required,min=1"`
+	Days      uint     `json:"days" binding:"min=1" example:"30"`
+}
+
+type rentResponse struct {
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+func handleRentBitmark() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req rentRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+
+		err := client.RentBitmarkToMany(acct, req.BitmarkId, req.Renters, req.Days)
+		if errs, ok := err.(sdk.BulkLeaseError); ok {
+			failed := make(map[string]string)
+			for renter, e := range errs {
+				failed[renter] = e.Error()
+			}
+			c.JSON(http.StatusMultiStatus, rentResponse{failed})
+			return
+		}
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, rentResponse{})
+	}
+}
+
+type leaseRequest struct {
+	accountRequest
+	BitmarkId string `json:"bitmark_id" binding:"required" example:"f1b5a3c5c6ac6f2ec21bb7b3ed3ebeb1a16cd6e1a1d0c2ba9e3b7e57e5f1c1a9"`
+	Renter    string `json:"renter" binding:"required"`
+	Days      uint   `json:"days"`
+}
+
+func handleRenewLease() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req leaseRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+		if req.Days == 0 {
+			abortWithBadRequest(c, "days not set")
+			return
+		}
+
+		if err := client.RenewLease(acct, req.BitmarkId, req.Renter, req.Days); err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
+
+func handleRevokeLease() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req leaseRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+
+		if err := client.RevokeLease(acct, req.BitmarkId, req.Renter); err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
+
+type listLeasesResponse struct {
+	Leases []*sdk.Lease `json:"leases"`
+}
+
+func handleListLeases() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req accountRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+
+		leases, err := client.ListLeases(acct)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, listLeasesResponse{leases})
+	}
+}
+
+func handleListGrantedLeases() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req accountRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+
+		leases, err := client.ListGrantedLeases(acct)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, listLeasesResponse{leases})
+	}
+}
+
+type bitmarksQuery struct {
+	AssetId   string `form:"asset_id"`
+	Issuer    string `form:"issuer"`
+	Owner     string `form:"owner"`
+	OwnerSent bool   `form:"owner_sent"`
+	Asset     bool   `form:"asset"`
+	Pending   bool   `form:"pending"`
+	To        string `form:"to"`
+	At        uint   `form:"at"`
+	Limit     uint   `form:"limit"`
+}
+
+type bitmarksResponse struct {
+	Bitmarks []*sdk.Bitmark `json:"bitmarks"`
+}
+
+func handleQueryBitmarks() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var q bitmarksQuery
+		if err := c.ShouldBindQuery(&q); err != nil {
+			abortWithBadRequest(c, "invalid query: "+err.Error())
+			return
+		}
+
+		filter := sdk.BitmarkFilter(q)
+		bitmarks, err := client.QueryBitmarks(&filter)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, bitmarksResponse{bitmarks})
+	}
+}
+
+func handleGetBitmark() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bitmark, err := client.GetBitmark(c.Param("id"))
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, bitmark)
 	}
 }
 
 type downloadRequest struct {
-	Seed      string `json:"seed"`
-	BitmarkId string `json:"bitmark_id"`
+	accountRequest
+	BitmarkId string `json:"bitmark_id" binding:"required" example:"f1b5a3c5c6ac6f2ec21bb7b3ed3ebeb1a16cd6e1a1d0c2ba9e3b7e57e5f1c1a9"`
 }
 
 func handleDownloadAsset() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var req transferRequest
-		if err := c.BindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid request body"})
+		var req downloadRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
 			return
 		}
 
-		acct, err := sdk.AccountFromSeed(req.Seed)
+		fileName, content, err := client.DownloadAsset(acct, req.BitmarkId)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid seed"})
+			abortWithError(c, err)
 			return
 		}
 
-		content, err := acct.DownloadAsset(req.BitmarkId)
+		serveContent(c, fileName, content)
+	}
+}
+
+func handleDownloadAssetByLease() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req downloadRequest
+		acct, ok := bindAccountRequest(c, &req)
+		if !ok {
+			return
+		}
+
+		leases, err := client.ListLeases(acct)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		var lease *sdk.Lease
+		for _, l := range leases {
+			if l.BitmarkId == req.BitmarkId {
+				lease = l
+				break
+			}
+		}
+		if lease == nil {
+			abortWithNotFound(c, "lease not found")
+			return
+		}
+
+		content, err := client.DownloadAssetByLease(acct, lease)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		serveContent(c, "", content)
+	}
+}
+
+func serveContent(c *gin.Context, fileName string, content []byte) {
+	if fileName != "" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	}
+	c.Data(http.StatusOK, http.DetectContentType(content), content)
+}
+
+type issueJobRequest struct {
+	accountRequest
+	AssetId  string `json:"asset_id" binding:"required" example:"0e0b4e3bd771811d35a23707ba6197aa1dd5937439a221eaf8e7909309e7b31b6c0e06a1001c261a099abf04c560199db898bc154cf128aa9efa5efd36030c64"`
+	Quantity int    `json:"quantity" binding:"min=1,max=100000" example:"10"`
+}
+
+func handleSubmitIssueJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req issueJobRequest
+		if _, ok := bindAccountRequest(c, &req); !ok {
+			return
+		}
+		if max := maxJobItems(); req.Quantity > max {
+			abortWithBadRequest(c, fmt.Sprintf("quantity exceeds the limit of %d per job", max))
+			return
+		}
+
+		j, err := jobs.submitIssue(c.GetString(apiKeyContextKey), req.Account, req.AssetId, req.Quantity)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, j)
+	}
+}
+
+type transferItem struct {
+	BitmarkId string `json:"bitmark_id" binding:"required" example:"f1b5a3c5c6ac6f2ec21bb7b3ed3ebeb1a16cd6e1a1d0c2ba9e3b7e57e5f1c1a9"`
+	Receiver  string `json:"receiver" binding:"required" example:"eZpG6Wi9SQvpDatEP7QGrx6nvzwd6s6R8DgMKgDbDY1R5bjzb9"`
+}
+
+type transferJobRequest struct {
+	accountRequest
+	Transfers []transferItem `json:"transfers" binding:"required,min=1,max=100000,dive"`
+}
+
+func handleSubmitTransferJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req transferJobRequest
+		if _, ok := bindAccountRequest(c, &req); !ok {
+			return
+		}
+		if max := maxJobItems(); len(req.Transfers) > max {
+			abortWithBadRequest(c, fmt.Sprintf("transfers exceed the limit of %d per job", max))
+			return
+		}
+
+		j, err := jobs.submitTransfer(c.GetString(apiKeyContextKey), req.Account, req.Transfers)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, j)
+	}
+}
+
+func handleGetJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		j, err := jobs.get(c.GetString(apiKeyContextKey), c.Param("id"))
+		if err != nil {
+			abortWithNotFound(c, err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, j)
+	}
+}
+
+type jobResultsResponse struct {
+	Status  jobStatus      `json:"status"`
+	Results []string       `json:"results"`
+	Failed  map[int]string `json:"failed,omitempty"`
+}
+
+// handleGetJobResults returns the bitmark ids or transaction ids of the finished items,
+// and the errors of the failed items by index
+func handleGetJobResults() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		j, err := jobs.get(c.GetString(apiKeyContextKey), c.Param("id"))
+		if err != nil {
+			abortWithNotFound(c, err.Error())
+			return
+		}
+
+		resp := jobResultsResponse{Status: j.Status, Results: make([]string, 0), Failed: make(map[int]string)}
+		for i, item := range j.Items {
+			resp.Results = append(resp.Results, item.Results...)
+			if item.Status == itemFailed {
+				resp.Failed[i] = item.Error
+			}
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func handleCancelJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		j, err := jobs.cancel(c.GetString(apiKeyContextKey), c.Param("id"))
+		if err == errJobNotFound {
+			abortWithNotFound(c, err.Error())
+			return
+		}
 		if err != nil {
-			log.Error(err.Error())
-			c.JSON(http.StatusBadRequest, gin.H{"message": "download failed"})
+			abortWithError(c, err)
 			return
 		}
 
-		c.Data(http.StatusOK, http.DetectContentType(content), content)
-		return
+		c.JSON(http.StatusOK, j)
 	}
 }
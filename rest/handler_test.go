@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveUpload(t *testing.T) {
+	root, err := ioutil.TempDir("", "uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	uploads := filepath.Join(root, "uploads")
+	secret := filepath.Join(root, "secret.conf")
+	for _, path := range []string{filepath.Join(uploads, "docs", "contract.pdf"), secret} {
+		os.MkdirAll(filepath.Dir(path), 0700)
+		if err := ioutil.WriteFile(path, []byte("content"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(secret, filepath.Join(uploads, "link.conf")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(root, filepath.Join(uploads, "parent")); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg = &config{}
+	if _, err := resolveUpload("docs/contract.pdf"); err == nil {
+		t.Error("resolved without an upload directory")
+	}
+
+	cfg = &config{UploadDir: uploads}
+	path, err := resolveUpload("docs/contract.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "contract.pdf" {
+		t.Errorf("resolved to %s", path)
+	}
+
+	for _, name := range []string{
+		secret,
+		"../secret.conf",
+		"docs/../../secret.conf",
+		"link.conf",
+		"parent/secret.conf",
+		"docs",
+		"missing.pdf",
+		"",
+	} {
+		if path, err := resolveUpload(name); err == nil {
+			t.Errorf("%q resolved to %s", name, path)
+		}
+	}
+}
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+)
+
+const (
+	issueBatchSize     = 100
+	defaultJobWorkers  = 4
+	defaultMaxJobItems = 10000
+)
+
+type jobKind string
+
+const (
+	jobIssue    jobKind = "issue"
+	jobTransfer jobKind = "transfer"
+)
+
+type jobStatus string
+
+const (
+	jobQueued    jobStatus = "queued"
+	jobRunning   jobStatus = "running"
+	jobCompleted jobStatus = "completed"
+	jobCancelled jobStatus = "cancelled"
+)
+
+type itemStatus string
+
+const (
+	itemPending   itemStatus = "pending"
+	itemRunning   itemStatus = "running"
+	itemDone      itemStatus = "done"
+	itemFailed    itemStatus = "failed"
+	itemCancelled itemStatus = "cancelled"
+)
+
+var errJobNotFound = errors.New("job not found")
+
+// jobRetryDelay is the delay before a job is run again after it failed to be saved
+var jobRetryDelay = 10 * time.Second
+
+// maxJobItems returns the configured limit of the bitmarks issued or transferred by a job
+func maxJobItems() int {
+	if cfg.MaxJobItems > 0 {
+		return cfg.MaxJobItems
+	}
+	return defaultMaxJobItems
+}
+
+// jobItem is a unit of work of a job: a batch of issues or a single transfer.
+// The nonces of an issue batch are fixed at submission, so a batch retried
+// after a restart yields the same bitmark ids.
+type jobItem struct {
+	Status    itemStatus `json:"status"`
+	Nonces    []uint64   `json:"nonces,omitempty"`
+	BitmarkId string     `json:"bitmark_id,omitempty"`
+	Receiver  string     `json:"receiver,omitempty"`
+	Results   []string   `json:"results,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type job struct {
+	Id        string    `json:"id"`
+	Kind      jobKind   `json:"kind"`
+	Status    jobStatus `json:"status"`
+	APIKey    string    `json:"-"`
+	Account   string    `json:"account"`
+	AssetId   string    `json:"asset_id,omitempty"`
+	Items     []jobItem `json:"items"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// unsaved is set when the last change failed to be persisted
+	unsaved bool
+}
+
+// jobFile is the persisted form of a job, which keeps the owner API key
+type jobFile struct {
+	*job
+	APIKey string `json:"api_key"`
+}
+
+// itemUpdate is a line of the item log of a job, applied over the job file
+type itemUpdate struct {
+	Index     int       `json:"index"`
+	Item      jobItem   `json:"item"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (j *job) copy() *job {
+	c := *j
+	c.Items = append([]jobItem(nil), j.Items...)
+	return &c
+}
+
+// jobQueue runs the jobs in a pool of workers. Every state change is persisted
+// under dir, and the unfinished jobs are queued again when the queue is opened.
+// A job is saved as a whole when its status changes, and the updates of its items
+// are appended to its item log in between.
+type jobQueue struct {
+	sync.Mutex
+	dir   string
+	jobs  map[string]*job
+	queue chan string
+}
+
+func openJobQueue(dir string, workers int) (*jobQueue, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	q := &jobQueue{
+		dir:   dir,
+		jobs:  make(map[string]*job),
+		queue: make(chan string, 1024),
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	unfinished := make([]string, 0)
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+
+		dat, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		jf := jobFile{job: &job{}}
+		if err := json.Unmarshal(dat, &jf); err != nil {
+			return nil, err
+		}
+		j := jf.job
+		j.APIKey = jf.APIKey
+		if err := q.replay(j); err != nil {
+			return nil, err
+		}
+
+		q.jobs[j.Id] = j
+		if j.Status == jobQueued || j.Status == jobRunning {
+			unfinished = append(unfinished, j.Id)
+		}
+	}
+
+	if workers <= 0 {
+		workers = defaultJobWorkers
+	}
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+
+	for _, id := range unfinished {
+		log.Infof("resume job %s", id)
+		q.enqueue(id)
+	}
+
+	return q, nil
+}
+
+// submitIssue splits the quantity into batches and queues the issuance
+func (q *jobQueue) submitIssue(apiKey, account, assetId string, quantity int) (*job, error) {
+	items := make([]jobItem, 0, quantity/issueBatchSize+1)
+	for quantity > 0 {
+		n := quantity
+		if n > issueBatchSize {
+			n = issueBatchSize
+		}
+		quantity -= n
+
+		nonces := make([]uint64, n)
+		buf := make([]byte, 8*n)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		for i := range nonces {
+			nonces[i] = binary.BigEndian.Uint64(buf[i*8:])
+		}
+		items = append(items, jobItem{Status: itemPending, Nonces: nonces})
+	}
+
+	return q.submit(&job{Kind: jobIssue, APIKey: apiKey, Account: account, AssetId: assetId, Items: items})
+}
+
+func (q *jobQueue) submitTransfer(apiKey, account string, transfers []transferItem) (*job, error) {
+	items := make([]jobItem, len(transfers))
+	for i, t := range transfers {
+		items[i] = jobItem{Status: itemPending, BitmarkId: t.BitmarkId, Receiver: t.Receiver}
+	}
+
+	return q.submit(&job{Kind: jobTransfer, APIKey: apiKey, Account: account, Items: items})
+}
+
+func (q *jobQueue) submit(j *job) (*job, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	j.Id = hex.EncodeToString(b)
+	j.Status = jobQueued
+	j.CreatedAt = time.Now().UTC()
+	j.UpdatedAt = j.CreatedAt
+
+	q.Lock()
+	err := q.save(j)
+	if err == nil {
+		q.jobs[j.Id] = j
+	}
+	snapshot := j.copy()
+	q.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	q.enqueue(j.Id)
+	return snapshot, nil
+}
+
+// get returns a snapshot of the job if it belongs to the API key
+func (q *jobQueue) get(apiKey, id string) (*job, error) {
+	q.Lock()
+	defer q.Unlock()
+
+	j, ok := q.jobs[id]
+	if !ok || j.APIKey != apiKey {
+		return nil, errJobNotFound
+	}
+	return j.copy(), nil
+}
+
+// cancel stops the job after the item in progress, and cancels the pending items
+func (q *jobQueue) cancel(apiKey, id string) (*job, error) {
+	q.Lock()
+	defer q.Unlock()
+
+	j, ok := q.jobs[id]
+	if !ok || j.APIKey != apiKey {
+		return nil, errJobNotFound
+	}
+
+	if j.Status == jobQueued || j.Status == jobRunning {
+		j.Status = jobCancelled
+		for i := range j.Items {
+			if j.Items[i].Status == itemPending {
+				j.Items[i].Status = itemCancelled
+			}
+		}
+		if err := q.save(j); err != nil {
+			return nil, err
+		}
+	}
+	return j.copy(), nil
+}
+
+func (q *jobQueue) enqueue(id string) {
+	go func() { q.queue <- id }()
+}
+
+func (q *jobQueue) work() {
+	for id := range q.queue {
+		q.run(id)
+	}
+}
+
+func (q *jobQueue) run(id string) {
+	q.Lock()
+	j := q.jobs[id]
+	if j.Status == jobCancelled || j.Status == jobCompleted {
+		var err error
+		if j.unsaved {
+			err = q.save(j)
+		}
+		q.Unlock()
+		if err != nil {
+			q.retryLater(id, err)
+		}
+		return
+	}
+	j.Status = jobRunning
+	err := q.save(j)
+	q.Unlock()
+	if err != nil {
+		q.retryLater(id, err)
+		return
+	}
+
+	acct, openErr := accounts.open(j.Account)
+
+	for i := range j.Items {
+		q.Lock()
+		if j.Status == jobCancelled {
+			q.Unlock()
+			break
+		}
+		item := j.Items[i]
+		resumed := item.Status == itemRunning
+		if item.Status != itemPending && !resumed {
+			q.Unlock()
+			continue
+		}
+		// an item left running by a failed save is resumed by the next run
+		j.Items[i].Status = itemRunning
+		err := q.saveItem(j, i)
+		q.Unlock()
+		if err != nil {
+			q.retryLater(id, err)
+			return
+		}
+
+		var results []string
+		err = openErr
+		if err == nil {
+			results, err = q.process(j, acct, item, resumed)
+		}
+
+		q.Lock()
+		j.Items[i].Results = results
+		if err != nil {
+			j.Items[i].Status = itemFailed
+			j.Items[i].Error = err.Error()
+		} else {
+			j.Items[i].Status = itemDone
+		}
+		err = q.saveItem(j, i)
+		q.Unlock()
+		if err != nil {
+			q.retryLater(id, err)
+			return
+		}
+	}
+
+	q.Lock()
+	if j.Status == jobRunning {
+		j.Status = jobCompleted
+	}
+	err = q.save(j)
+	q.Unlock()
+	if err != nil {
+		q.retryLater(id, err)
+	}
+}
+
+// retryLater runs the job again, resuming from its state in memory, after a failure to save it
+func (q *jobQueue) retryLater(id string, err error) {
+	log.Errorf("unable to save job %s, retry in %s: %s", id, jobRetryDelay, err)
+	time.AfterFunc(jobRetryDelay, func() { q.enqueue(id) })
+}
+
+// process performs a single item. An item interrupted by a restart is
+// not repeated if its issues or transfer already reached the chain.
+func (q *jobQueue) process(j *job, acct *sdk.Account, item jobItem, resumed bool) ([]string, error) {
+	switch j.Kind {
+	case jobIssue:
+		issues, err := sdk.NewIssueRecords(j.AssetId, acct, len(item.Nonces), item.Nonces...)
+		if err != nil {
+			return nil, err
+		}
+		bitmarkIds, err := client.Issue(nil, issues)
+		if err != nil && resumed {
+			if ids, ok := issuedBefore(issues); ok {
+				return ids, nil
+			}
+		}
+		return bitmarkIds, err
+	case jobTransfer:
+		if resumed {
+			// the transfer of the interrupted attempt may still be pending
+			bmk, err := client.GetPendingBitmark(item.BitmarkId)
+			if err == nil && bmk.Owner == item.Receiver {
+				return []string{bmk.HeadId}, nil
+			}
+		}
+		txId, err := client.Transfer(acct, item.BitmarkId, item.Receiver)
+		if err != nil {
+			return nil, err
+		}
+		return []string{txId}, nil
+	}
+	return nil, errors.New("unknown job kind")
+}
+
+// issuedBefore reports whether every issue of the batch is already on the chain,
+// pending or confirmed. The id of a bitmark is the id of its issue.
+func issuedBefore(issues []*sdk.IssueRecord) ([]string, bool) {
+	ids := make([]string, len(issues))
+	for i, issue := range issues {
+		id, err := issue.Id()
+		if err != nil {
+			return nil, false
+		}
+		if _, err := client.GetTx(id); err != nil {
+			return nil, false
+		}
+		ids[i] = id
+	}
+	return ids, true
+}
+
+func (q *jobQueue) jobPath(id string) string {
+	return filepath.Join(q.dir, id+".json")
+}
+
+func (q *jobQueue) itemLogPath(id string) string {
+	return filepath.Join(q.dir, id+".log")
+}
+
+// save persists the job as a whole and drops its item log; the caller holds the lock
+func (q *jobQueue) save(j *job) error {
+	j.UpdatedAt = time.Now().UTC()
+	j.unsaved = true
+
+	dat, err := json.Marshal(jobFile{j, j.APIKey})
+	if err != nil {
+		return err
+	}
+
+	path := q.jobPath(j.Id)
+	if err := ioutil.WriteFile(path+".tmp", dat, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	if err := os.Remove(q.itemLogPath(j.Id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	j.unsaved = false
+	return nil
+}
+
+// saveItem appends the item to the item log of the job; the caller holds the lock
+func (q *jobQueue) saveItem(j *job, i int) error {
+	j.UpdatedAt = time.Now().UTC()
+	j.unsaved = true
+
+	dat, err := json.Marshal(itemUpdate{i, j.Items[i], j.UpdatedAt})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(q.itemLogPath(j.Id), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(dat, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	j.unsaved = false
+	return nil
+}
+
+// replay applies the item log of the job read from its file. A line
+// partially written when the server stopped ends the log.
+func (q *jobQueue) replay(j *job) error {
+	f, err := os.Open(q.itemLogPath(j.Id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	for {
+		var u itemUpdate
+		err := dec.Decode(&u)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if u.Index < 0 || u.Index >= len(j.Items) {
+			return errors.New("item log of job " + j.Id + " out of range")
+		}
+		j.Items[u.Index] = u.Item
+		j.UpdatedAt = u.UpdatedAt
+	}
+}
@@ -0,0 +1,533 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+	"github.com/bitmark-inc/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const testAssetId = "0e0b4e3bd771811d35a23707ba6197aa1dd5937439a221eaf8e7909309e7b31b6c0e06a1001c261a099abf04c560199db898bc154cf128aa9efa5efd36030c64"
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		panic(err)
+	}
+	if err := logger.Initialise(logger.Configuration{
+		Directory: dir,
+		File:      "test.log",
+		Size:      1048576,
+		Count:     1,
+		Levels:    map[string]string{"DEFAULT": "info"},
+	}); err != nil {
+		panic(err)
+	}
+	log = logger.New("test")
+
+	code := m.Run()
+	logger.Finalise()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// issueAPI serves the issues of the jobs, counting the issue requests
+type issueAPI struct {
+	sync.Mutex
+	requests int
+	issued   map[string]bool // bitmark ids, pending until queried with pending=true
+
+	// block holds the issue requests until it is closed, if set
+	block chan struct{}
+}
+
+func (a *issueAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/v1/issue":
+		if a.block != nil {
+			<-a.block
+		}
+
+		var body struct {
+			Issues []*sdk.IssueRecord `json:"issues"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		a.Lock()
+		defer a.Unlock()
+		a.requests++
+
+		txs := make([]map[string]string, 0)
+		for _, issue := range body.Issues {
+			id, _ := issue.Id()
+			if a.issued[id] {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code": 1000, "message": "transaction already exists"}`))
+				return
+			}
+			txs = append(txs, map[string]string{"txId": id})
+		}
+		for _, tx := range txs {
+			a.issued[tx["txId"]] = true
+		}
+		json.NewEncoder(w).Encode(txs)
+	case strings.HasPrefix(r.URL.Path, "/v1/txs/"):
+		id := strings.TrimPrefix(r.URL.Path, "/v1/txs/")
+
+		a.Lock()
+		defer a.Unlock()
+		if !a.issued[id] || r.URL.Query().Get("pending") != "true" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code": 1000, "message": "not found"}`))
+			return
+		}
+		fmt.Fprintf(w, `{"tx": {"id": %q, "status": "pending"}}`, id)
+	case strings.HasPrefix(r.URL.Path, "/v1/bitmarks/"):
+		// the issued bitmarks are pending, and not returned without pending=true
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code": 1000, "message": "not found"}`))
+	default:
+		w.Write([]byte("{}"))
+	}
+}
+
+// newTestJobQueue opens a job queue and a vault account issuing to the API
+func newTestJobQueue(t *testing.T, api *issueAPI) (q *jobQueue, handle string, cleanup func()) {
+	v, dir, closeVault := newTestVault(t)
+	accounts = v
+
+	server := httptest.NewServer(api)
+	client = sdk.NewClient(&sdk.Config{Network: "testnet", APIEndpoint: server.URL})
+	cleanup = func() {
+		server.Close()
+		closeVault()
+	}
+
+	acct, err := client.CreateAccount()
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	if handle, err = v.store(acct, "ops"); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+
+	if q, err = openJobQueue(filepath.Join(dir, "jobs"), 2); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	return q, handle, cleanup
+}
+
+// waitForJob waits until the job leaves the queued and running status
+func waitForJob(t *testing.T, q *jobQueue, id string) *job {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		j, err := q.get("ops", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j.Status != jobQueued && j.Status != jobRunning {
+			return j
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s not finished", id)
+	return nil
+}
+
+func TestIssueJobPersisted(t *testing.T) {
+	api := &issueAPI{issued: make(map[string]bool)}
+	q, handle, cleanup := newTestJobQueue(t, api)
+	defer cleanup()
+
+	submitted, err := q.submitIssue("ops", handle, testAssetId, issueBatchSize+20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submitted.Items) != 2 || len(submitted.Items[0].Nonces) != issueBatchSize || len(submitted.Items[1].Nonces) != 20 {
+		t.Fatalf("unexpected batches: %+v", submitted.Items)
+	}
+
+	j := waitForJob(t, q, submitted.Id)
+	if j.Status != jobCompleted {
+		t.Fatalf("status %s", j.Status)
+	}
+	for i, item := range j.Items {
+		if item.Status != itemDone || len(item.Results) != len(item.Nonces) {
+			t.Fatalf("item %d: %+v", i, item)
+		}
+	}
+
+	reopened, err := openJobQueue(q.dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	persisted, err := reopened.get("ops", j.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if persisted.Status != jobCompleted || persisted.Items[1].Results[19] != j.Items[1].Results[19] {
+		t.Fatalf("persisted %+v", persisted)
+	}
+
+	if _, err := reopened.get("other", j.Id); err != errJobNotFound {
+		t.Fatalf("job of another key: %v", err)
+	}
+}
+
+func TestIssueJobResumed(t *testing.T) {
+	api := &issueAPI{issued: make(map[string]bool)}
+	q, handle, cleanup := newTestJobQueue(t, api)
+	defer cleanup()
+
+	acct, err := accounts.open(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first batch reached the chain and is still pending when the server stopped
+	j := &job{
+		Id:      "5c1f0e2a7b3d4c6e8f9a0b1c2d3e4f50",
+		Kind:    jobIssue,
+		Status:  jobRunning,
+		APIKey:  "ops",
+		Account: handle,
+		AssetId: testAssetId,
+		Items: []jobItem{
+			{Status: itemRunning, Nonces: []uint64{1, 2, 3}},
+			{Status: itemPending, Nonces: []uint64{4, 5}},
+		},
+	}
+	issues, err := sdk.NewIssueRecords(testAssetId, acct, 3, 1, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, issue := range issues {
+		id, _ := issue.Id()
+		api.issued[id] = true
+	}
+
+	dir := filepath.Join(filepath.Dir(q.dir), "resumed")
+	os.MkdirAll(dir, 0700)
+	dat, _ := json.Marshal(jobFile{j, j.APIKey})
+	if err := ioutil.WriteFile(filepath.Join(dir, j.Id+".json"), dat, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := openJobQueue(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := waitForJob(t, resumed, j.Id)
+	if done.Status != jobCompleted {
+		t.Fatalf("status %s", done.Status)
+	}
+	for i, item := range done.Items {
+		if item.Status != itemDone || len(item.Results) != len(item.Nonces) {
+			t.Fatalf("item %d: %+v", i, item)
+		}
+	}
+	if id, _ := issues[0].Id(); done.Items[0].Results[0] != id {
+		t.Fatalf("resumed batch issued %s, expected %s", done.Items[0].Results[0], id)
+	}
+	if len(api.issued) != 5 {
+		t.Fatalf("%d bitmarks issued", len(api.issued))
+	}
+}
+
+func TestJobItemLog(t *testing.T) {
+	api := &issueAPI{issued: make(map[string]bool)}
+	q, handle, cleanup := newTestJobQueue(t, api)
+	defer cleanup()
+
+	acct, err := accounts.open(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := sdk.NewIssueRecords(testAssetId, acct, 2, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued := make([]string, len(issues))
+	for i, issue := range issues {
+		issued[i], _ = issue.Id()
+	}
+
+	// the first batch is done in the item log, followed by a line cut by the stop
+	j := &job{
+		Id:      "7e3f1a2b9c4d5e6f708192a3b4c5d6e7",
+		Kind:    jobIssue,
+		Status:  jobRunning,
+		APIKey:  "ops",
+		Account: handle,
+		AssetId: testAssetId,
+		Items: []jobItem{
+			{Status: itemPending, Nonces: []uint64{1, 2}},
+			{Status: itemPending, Nonces: []uint64{3}},
+		},
+	}
+	dir := filepath.Join(filepath.Dir(q.dir), "logged")
+	os.MkdirAll(dir, 0700)
+	dat, _ := json.Marshal(jobFile{j, j.APIKey})
+	if err := ioutil.WriteFile(filepath.Join(dir, j.Id+".json"), dat, 0600); err != nil {
+		t.Fatal(err)
+	}
+	running, _ := json.Marshal(itemUpdate{0, jobItem{Status: itemRunning, Nonces: []uint64{1, 2}}, time.Now()})
+	done, _ := json.Marshal(itemUpdate{0, jobItem{Status: itemDone, Nonces: []uint64{1, 2}, Results: issued}, time.Now()})
+	logged := string(running) + "\n" + string(done) + "\n" + `{"index": 1, "item": {"sta`
+	if err := ioutil.WriteFile(filepath.Join(dir, j.Id+".log"), []byte(logged), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := openJobQueue(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	completed := waitForJob(t, resumed, j.Id)
+	if completed.Status != jobCompleted || completed.Items[0].Results[1] != issued[1] || completed.Items[1].Status != itemDone {
+		t.Fatalf("completed %+v", completed)
+	}
+	api.Lock()
+	requests := api.requests
+	api.Unlock()
+	if requests != 1 {
+		t.Fatalf("%d issue requests", requests)
+	}
+
+	// the item log is merged into the job file once the job is saved as a whole
+	if _, err := os.Stat(filepath.Join(dir, j.Id+".log")); !os.IsNotExist(err) {
+		t.Fatalf("item log kept: %v", err)
+	}
+	reopened, err := openJobQueue(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if persisted, _ := reopened.get("ops", j.Id); persisted.Items[1].Status != itemDone {
+		t.Fatalf("persisted %+v", persisted)
+	}
+}
+
+func TestJobSaveFailure(t *testing.T) {
+	jobRetryDelay = 10 * time.Millisecond
+	defer func() { jobRetryDelay = 10 * time.Second }()
+
+	api := &issueAPI{issued: make(map[string]bool), block: make(chan struct{})}
+	q, handle, cleanup := newTestJobQueue(t, api)
+	defer cleanup()
+
+	submitted, err := q.submitIssue("ops", handle, testAssetId, 2*issueBatchSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		j, _ := q.get("ops", submitted.Id)
+		if j.Items[0].Status == itemRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job not started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the item log can't be written until the directory in its place is removed
+	logPath := filepath.Join(q.dir, submitted.Id+".log")
+	os.Remove(logPath)
+	if err := os.MkdirAll(filepath.Join(logPath, "blocked"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	close(api.block)
+
+	time.Sleep(100 * time.Millisecond)
+	if j, _ := q.get("ops", submitted.Id); j.Status != jobRunning || j.Items[0].Status != itemDone || j.Items[1].Status != itemPending {
+		t.Fatalf("job run without saving: %+v", j)
+	}
+
+	os.RemoveAll(logPath)
+	j := waitForJob(t, q, submitted.Id)
+	if j.Status != jobCompleted || j.Items[1].Status != itemDone {
+		t.Fatalf("job %+v", j)
+	}
+	api.Lock()
+	requests := api.requests
+	api.Unlock()
+	if requests != 2 {
+		t.Fatalf("%d issue requests", requests)
+	}
+}
+
+func TestTransferJobResumed(t *testing.T) {
+	q, handle, cleanup := newTestJobQueue(t, &issueAPI{issued: make(map[string]bool)})
+	defer cleanup()
+
+	acct, err := accounts.open(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, err := client.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the transfer reached the chain and is still pending when the server stopped
+	const bitmarkId, txId = "f1b5a3c5c6ac6f2ec21bb7b3ed3ebeb1a16cd6e1a1d0c2ba9e3b7e57e5f1c1a9", "8f6d2e3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+	var mu sync.Mutex
+	requests := make([]string, 0)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/bitmarks/"+bitmarkId {
+			mu.Lock()
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			mu.Unlock()
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": 1000, "message": "double spend"}`))
+			return
+		}
+
+		bmk := &sdk.Bitmark{Id: bitmarkId, HeadId: bitmarkId, Owner: acct.AccountNumber(), Status: "confirmed"}
+		if r.URL.Query().Get("pending") == "true" {
+			bmk.HeadId, bmk.Owner, bmk.Status = txId, receiver.AccountNumber(), "pending"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"bitmark": bmk})
+	}))
+	defer api.Close()
+	client = sdk.NewClient(&sdk.Config{Network: "testnet", APIEndpoint: api.URL})
+
+	j := &job{
+		Id:      "6d2e0f1a8b3c4d5e6f708192a3b4c5d6",
+		Kind:    jobTransfer,
+		Status:  jobRunning,
+		APIKey:  "ops",
+		Account: handle,
+		Items:   []jobItem{{Status: itemRunning, BitmarkId: bitmarkId, Receiver: receiver.AccountNumber()}},
+	}
+	dir := filepath.Join(filepath.Dir(q.dir), "resumed")
+	os.MkdirAll(dir, 0700)
+	dat, _ := json.Marshal(jobFile{j, j.APIKey})
+	if err := ioutil.WriteFile(filepath.Join(dir, j.Id+".json"), dat, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := openJobQueue(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := waitForJob(t, resumed, j.Id)
+	if item := done.Items[0]; item.Status != itemDone || len(item.Results) != 1 || item.Results[0] != txId {
+		t.Fatalf("item %+v", item)
+	}
+	if len(requests) != 0 {
+		t.Fatalf("transfer submitted again: %v", requests)
+	}
+}
+
+func TestJobCancel(t *testing.T) {
+	api := &issueAPI{issued: make(map[string]bool), block: make(chan struct{})}
+	q, handle, cleanup := newTestJobQueue(t, api)
+	defer cleanup()
+
+	submitted, err := q.submitIssue("ops", handle, testAssetId, 3*issueBatchSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// cancel while the first batch is issued
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		j, _ := q.get("ops", submitted.Id)
+		if j.Items[0].Status == itemRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job not started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := q.cancel("other", submitted.Id); err != errJobNotFound {
+		t.Fatalf("cancel by another key: %v", err)
+	}
+	cancelled, err := q.cancel("ops", submitted.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != jobCancelled || cancelled.Items[1].Status != itemCancelled || cancelled.Items[2].Status != itemCancelled {
+		t.Fatalf("cancelled %+v", cancelled)
+	}
+	close(api.block)
+
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		j, _ := q.get("ops", submitted.Id)
+		if j.Items[0].Status == itemDone {
+			if j.Status != jobCancelled {
+				t.Fatalf("status %s", j.Status)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("batch in progress not finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	reopened, err := openJobQueue(q.dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	api.Lock()
+	requests := api.requests
+	api.Unlock()
+	if requests != 1 {
+		t.Fatalf("%d issue requests", requests)
+	}
+	if j, _ := reopened.get("ops", submitted.Id); j.Status != jobCancelled {
+		t.Fatalf("reopened status %s", j.Status)
+	}
+}
+
+func TestJobItemsLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	api := &issueAPI{issued: make(map[string]bool)}
+	q, handle, cleanup := newTestJobQueue(t, api)
+	defer cleanup()
+	jobs = q
+
+	cfg = &config{APIKeys: map[string]apiKeyConfig{"ops": {Key: "0f3c9a7d"}}, MaxJobItems: 5}
+	r := newRouter()
+
+	for quantity, status := range map[int]int{
+		5:      http.StatusAccepted,
+		6:      http.StatusBadRequest,
+		200000: http.StatusBadRequest,
+	} {
+		req := issueJobRequest{accountRequest{handle}, testAssetId, quantity}
+		if w := serveTestRequest(r, "POST", "/jobs/issue", "0f3c9a7d", req); w.Code != status {
+			t.Errorf("quantity %d: status %d", quantity, w.Code)
+		}
+	}
+
+	transfers := make([]transferItem, 6)
+	for i := range transfers {
+		transfers[i] = transferItem{"f1b5a3c5c6ac6f2ec21bb7b3ed3ebeb1a16cd6e1a1d0c2ba9e3b7e57e5f1c1a9", "eZpG6Wi9SQvpDatEP7QGrx6nvzwd6s6R8DgMKgDbDY1R5bjzb9"}
+	}
+	req := transferJobRequest{accountRequest{handle}, transfers}
+	if w := serveTestRequest(r, "POST", "/jobs/transfer", "0f3c9a7d", req); w.Code != http.StatusBadRequest {
+		t.Errorf("transfers: status %d", w.Code)
+	}
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
 
 	sdk "github.com/bitmark-inc/bitmark-sdk-go"
 	"github.com/bitmark-inc/logger"
@@ -12,23 +16,36 @@ import (
 )
 
 var (
-	cfg    *config
-	netork sdk.Network
-	log    *logger.L
+	cfg      *config
+	client   *sdk.Client
+	accounts *vault
+	jobs     *jobQueue
+	log      *logger.L
 )
 
+// config is the configuration of the server. The client is configured by the
+// keys of sdk.Settings in the same file.
 type config struct {
-	Chain   string `hcl:"network"`
+	Chain   string `hcl:"chain"` // deprecated by network
 	Port    int    `hcl:"port"`
 	DataDir string `hcl:"datadir"`
-}
 
-func init() {
-	var confpath string
-	flag.StringVar(&confpath, "conf", "", "Specify configuration file")
-	flag.Parse()
+	// UploadDir holds the asset files issued by path
+	UploadDir string `hcl:"upload_dir"`
+
+	VaultPassphrase string                  `hcl:"vault_passphrase"`
+	APIKeys         map[string]apiKeyConfig `hcl:"api_key"`
+	JobWorkers      int                     `hcl:"job_workers"`
+
+	// MaxJobItems limits the quantity of an issue job and the transfers of a transfer job
+	MaxJobItems int `hcl:"max_job_items"`
+}
 
+func setup(confpath string) {
 	cfg = readConfig(confpath)
+	if err := validateAPIKeys(cfg.APIKeys); err != nil {
+		panic(fmt.Sprintf("invalid configuration: %s", err))
+	}
 
 	if err := logger.Initialise(logger.Configuration{
 		Directory: cfg.DataDir,
@@ -40,12 +57,51 @@ func init() {
 		panic(fmt.Sprintf("logger initialization failed: %s", err))
 	}
 
-	netork = sdk.Livenet
-	if cfg.Chain == "test" {
-		netork = sdk.Testnet
+	settings := readSettings(confpath)
+
+	var err error
+	client, err = settings.NewClient()
+	if err != nil {
+		panic(fmt.Sprintf("unable to create the client: %s", err))
 	}
 
 	log = logger.New("")
+	log.Infof("client configuration: %s", settings)
+
+	passphrase := cfg.VaultPassphrase
+	if env := os.Getenv("BITMARK_VAULT_PASSPHRASE"); env != "" {
+		passphrase = env
+	}
+
+	accounts, err = openVault(filepath.Join(cfg.DataDir, "vault"), passphrase)
+	if err != nil {
+		panic(fmt.Sprintf("unable to open the vault: %s", err))
+	}
+
+	jobs, err = openJobQueue(filepath.Join(cfg.DataDir, "jobs"), cfg.JobWorkers)
+	if err != nil {
+		panic(fmt.Sprintf("unable to open the job queue: %s", err))
+	}
+}
+
+// readSettings loads the client settings from the configuration file and the environment
+func readSettings(confpath string) *sdk.Settings {
+	settings := sdk.DefaultSettings()
+	settings.Timeout = 10
+	if cfg.Chain == "test" {
+		settings.Network = "testnet"
+	}
+
+	if err := settings.LoadFile(confpath); err != nil {
+		panic(fmt.Sprintf("unable to read the configuration: %v", err))
+	}
+	if err := settings.LoadEnv(); err != nil {
+		panic(fmt.Sprintf("invalid configuration: %v", err))
+	}
+	if err := settings.Validate(); err != nil {
+		panic(fmt.Sprintf("invalid configuration: %v", err))
+	}
+	return settings
 }
 
 func readConfig(confpath string) *config {
@@ -63,11 +119,76 @@ func readConfig(confpath string) *config {
 	return &cfg
 }
 
-func main() {
+type route struct {
+	method  string
+	path    string
+	handler gin.HandlerFunc
+	doc     operation
+}
+
+func routes() []route {
+	return []route{
+		{"POST", "/account", handleCreateAccount(), operation{"Create an account in the vault", nil, accountResponse{}, 0, nil}},
+		{"GET", "/accounts", handleListAccounts(), operation{"List the accounts allowed for the API key", nil, listAccountsResponse{}, 0, nil}},
+
+		{"POST", "/assets", handleRegisterAsset(), operation{"Register an asset by its fingerprint", registerAssetRequest{}, registerAssetResponse{}, 0, nil}},
+		{"GET", "/bitmarks", handleQueryBitmarks(), operation{"Query bitmarks", bitmarksQuery{}, bitmarksResponse{}, 0, nil}},
+		{"GET", "/bitmarks/:id", handleGetBitmark(), operation{"Get a bitmark", nil, sdk.Bitmark{}, 0, nil}},
+
+		{"POST", "/issue", handleIssue(), operation{"Register and issue an asset file of the upload directory", issueRequest{}, issueResponse{}, 0, nil}},
+		{"POST", "/issue/asset", handleIssueByAssetId(), operation{"Issue bitmarks of a registered asset", issueByAssetIdRequest{}, issueResponse{}, 0, nil}},
+		{"POST", "/issue/fingerprint", handleIssueByFingerprint(), operation{"Register and issue an asset by its fingerprint", issueByFingerprintRequest{}, issueResponse{}, 0, nil}},
+
+		{"POST", "/transfer", handleTransfer(), operation{"Transfer a bitmark", transferRequest{}, txResponse{}, 0, nil}},
+		{"POST", "/transfer/countersign", handleCountersignTransfer(), operation{"Countersign and submit a transfer offer record", countersignRequest{}, txResponse{}, 0, nil}},
+
+		{"POST", "/offers", handleCreateOffer(), operation{"Sign a transfer offer and optionally submit it", createOfferRequest{}, createOfferResponse{}, 0, nil}},
+		{"POST", "/offers/list", handleListOffers(), operation{"List the transfer offers of an account", listOffersRequest{}, listOffersResponse{}, 0, nil}},
+		{"POST", "/offers/:id/accept", handleCompleteOffer(sdk.ActionAccept), operation{"Accept a transfer offer", accountRequest{}, txResponse{}, 0, nil}},
+		{"POST", "/offers/:id/reject", handleCompleteOffer(sdk.ActionReject), operation{"Reject a transfer offer", accountRequest{}, txResponse{}, 0, nil}},
+		{"POST", "/offers/:id/cancel", handleCompleteOffer(sdk.ActionCancel), operation{"Cancel a transfer offer", accountRequest{}, txResponse{}, 0, nil}},
+
+		{"POST", "/leases", handleRentBitmark(), operation{"Lease a private asset to renters", rentRequest{}, rentResponse{}, 0, map[int]interface{}{http.StatusMultiStatus: rentResponse{}}}},
+		{"POST", "/leases/renew", handleRenewLease(), operation{"Renew a lease", leaseRequest{}, nil, 0, nil}},
+		{"POST", "/leases/revoke", handleRevokeLease(), operation{"Revoke a lease", leaseRequest{}, nil, 0, nil}},
+		{"POST", "/leases/list", handleListLeases(), operation{"List the leases granted to an account", accountRequest{}, listLeasesResponse{}, 0, nil}},
+		{"POST", "/leases/granted", handleListGrantedLeases(), operation{"List the leases granted by an account", accountRequest{}, listLeasesResponse{}, 0, nil}},
+
+		{"POST", "/download", handleDownloadAsset(), operation{"Download the asset of an owned bitmark", downloadRequest{}, []byte{}, 0, nil}},
+		{"POST", "/download/lease", handleDownloadAssetByLease(), operation{"Download the asset of a leased bitmark", downloadRequest{}, []byte{}, 0, nil}},
+
+		{"POST", "/jobs/issue", handleSubmitIssueJob(), operation{"Submit an issuance job", issueJobRequest{}, job{}, http.StatusAccepted, nil}},
+		{"POST", "/jobs/transfer", handleSubmitTransferJob(), operation{"Submit a transfer job", transferJobRequest{}, job{}, http.StatusAccepted, nil}},
+		{"GET", "/jobs/:id", handleGetJob(), operation{"Get the status of a job and its items", nil, job{}, 0, nil}},
+		{"GET", "/jobs/:id/results", handleGetJobResults(), operation{"Get the results of a job", nil, jobResultsResponse{}, 0, nil}},
+		{"POST", "/jobs/:id/cancel", handleCancelJob(), operation{"Cancel a job", nil, job{}, 0, nil}},
+	}
+}
+
+func newRouter() *gin.Engine {
 	r := gin.Default()
-	r.POST("/account", handleCreateAccount())
-	r.POST("/issue", handleIssue())
-	r.POST("/transfer", handleTransfer())
-	r.POST("/download", handleDownloadAsset())
-	r.Run(fmt.Sprintf(":%d", cfg.Port))
+	r.GET("/openapi.json", handleOpenAPI())
+
+	r.Use(authenticate(cfg.APIKeys))
+	for _, rt := range routes() {
+		r.Handle(rt.method, rt.path, rt.handler)
+	}
+	return r
+}
+
+func main() {
+	var confpath string
+	var printSpec bool
+	flag.StringVar(&confpath, "conf", "", "Specify configuration file")
+	flag.BoolVar(&printSpec, "openapi", false, "Print the OpenAPI document and exit")
+	flag.Parse()
+
+	if printSpec {
+		dat, _ := json.MarshalIndent(openAPISpec(), "", "  ")
+		fmt.Println(string(dat))
+		return
+	}
+
+	setup(confpath)
+	newRouter().Run(fmt.Sprintf(":%d", cfg.Port))
 }
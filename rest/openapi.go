@@ -0,0 +1,373 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+	"github.com/gin-gonic/gin"
+)
+
+const openAPIVersion = "3.0.3"
+
+// operation documents a route. The request and responses are zero values of the
+// bound and returned types, from which the schemas are derived.
+type operation struct {
+	summary  string
+	request  interface{}
+	response interface{}
+	status   int
+
+	// other holds the other success responses by status, e.g. a partial success
+	other map[int]interface{}
+}
+
+// leaseDoc and sessionDataDoc mirror the wire format of the SDK types with a custom JSON encoding
+type leaseDoc struct {
+	BitmarkId  string           `json:"bitmark_id"`
+	AssetId    string           `json:"asset_id"`
+	Owner      string           `json:"owner"`
+	Renter     string           `json:"renter"`
+	URL        string           `json:"url"`
+	SessData   *sdk.SessionData `json:"session_data"`
+	Duration   uint             `json:"duration"`
+	Expiration int64            `json:"expiration_time"`
+}
+
+type sessionDataDoc struct {
+	EncryptedDataKey string `json:"enc_data_key"`
+	DataKeyAlgorithm string `json:"data_key_alg"`
+}
+
+var (
+	schemaOverrides = map[reflect.Type]reflect.Type{
+		reflect.TypeOf(sdk.Lease{}):       reflect.TypeOf(leaseDoc{}),
+		reflect.TypeOf(sdk.SessionData{}): reflect.TypeOf(sessionDataDoc{}),
+	}
+
+	enumValues = map[reflect.Type][]string{
+		reflect.TypeOf(sdk.Accessibility("")):          {string(sdk.Public), string(sdk.Private)},
+		reflect.TypeOf(sdk.TransferOfferDirection("")): {string(sdk.OfferIncoming), string(sdk.OfferOutgoing)},
+		reflect.TypeOf(sdk.TxStatus("")):               {string(sdk.TxPending), string(sdk.TxConfirmed)},
+		reflect.TypeOf(sdk.TransferOfferStatus("")): {
+			string(sdk.OfferStatusOpen), string(sdk.OfferStatusAccepted),
+			string(sdk.OfferStatusRejected), string(sdk.OfferStatusCancelled),
+		},
+	}
+
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	bytesType      = reflect.TypeOf([]byte{})
+
+	pathParamPattern = regexp.MustCompile(`:(\w+)`)
+)
+
+type object = map[string]interface{}
+
+// specBuilder derives the OpenAPI document from the route table
+type specBuilder struct {
+	schemas object
+}
+
+func openAPISpec() object {
+	b := &specBuilder{schemas: object{}}
+
+	paths := object{}
+	for _, rt := range routes() {
+		path := pathParamPattern.ReplaceAllString(rt.path, "{$1}")
+		item, ok := paths[path].(object)
+		if !ok {
+			item = object{}
+			paths[path] = item
+		}
+		item[strings.ToLower(rt.method)] = b.operation(rt)
+	}
+
+	b.schemaOf(reflect.TypeOf(errorResponse{}))
+
+	return object{
+		"openapi": openAPIVersion,
+		"info": object{
+			"title":       "Bitmark SDK REST gateway",
+			"description": "HTTP gateway to the Bitmark SDK. Accounts are kept in the gateway vault and referred to by handles.",
+			"version":     "1.0.0",
+		},
+		"paths": paths,
+		"components": object{
+			"schemas": b.schemas,
+			"responses": object{
+				"ClientError": errorResponseDoc("The request is invalid, unauthorized or conflicts with the chain state.", errorBody{
+					Kind:    kindInvalidRequest,
+					Message: "property metadata[\"\"] contains an empty key",
+					Details: []errorDetail{{Field: "metadata", Message: "contains an empty key"}},
+				}),
+				"ServerError": errorResponseDoc("The gateway or the Bitmark API failed. The code of the Bitmark API error is passed through.", errorBody{
+					Kind:    kindService,
+					Code:    1000,
+					Message: "invalid parameters",
+				}),
+			},
+			"securitySchemes": object{
+				"apiKey": object{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearer": object{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []object{{"apiKey": []string{}}, {"bearer": []string{}}},
+	}
+}
+
+func errorResponseDoc(description string, example errorBody) object {
+	return object{
+		"description": description,
+		"content": object{
+			"application/json": object{
+				"schema":  object{"$ref": "#/components/schemas/ErrorResponse"},
+				"example": errorResponse{example},
+			},
+		},
+	}
+}
+
+func (b *specBuilder) operation(rt route) object {
+	op := object{
+		"summary":     rt.doc.summary,
+		"operationId": operationId(rt),
+	}
+
+	params := make([]object, 0)
+	for _, m := range pathParamPattern.FindAllStringSubmatch(rt.path, -1) {
+		params = append(params, object{"name": m[1], "in": "path", "required": true, "schema": object{"type": "string"}})
+	}
+
+	if rt.doc.request != nil {
+		t := reflect.TypeOf(rt.doc.request)
+		if rt.method == http.MethodGet {
+			params = append(params, b.queryParameters(t)...)
+		} else {
+			op["requestBody"] = object{
+				"required": true,
+				"content":  object{"application/json": object{"schema": b.schemaOf(t)}},
+			}
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	responses := object{
+		"4XX": object{"$ref": "#/components/responses/ClientError"},
+		"5XX": object{"$ref": "#/components/responses/ServerError"},
+	}
+	for status, response := range successResponses(rt.doc) {
+		responses[strconv.Itoa(status)] = b.response(status, response)
+	}
+	op["responses"] = responses
+	return op
+}
+
+// successResponses returns the success responses of the operation by status
+func successResponses(doc operation) map[int]interface{} {
+	status := doc.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	responses := map[int]interface{}{status: doc.response}
+	for status, response := range doc.other {
+		responses[status] = response
+	}
+	return responses
+}
+
+func (b *specBuilder) response(status int, response interface{}) object {
+	var content object
+	switch {
+	case response == nil:
+		content = object{"application/json": object{"schema": object{"type": "object"}}}
+	case reflect.TypeOf(response) == bytesType:
+		content = object{"application/octet-stream": object{"schema": object{"type": "string", "format": "binary"}}}
+	default:
+		content = object{"application/json": object{"schema": b.schemaOf(reflect.TypeOf(response))}}
+	}
+	return object{"description": http.StatusText(status), "content": content}
+}
+
+// operationId joins the method and the path segments, e.g. postOffersByIdAccept
+func operationId(rt route) string {
+	id := strings.ToLower(rt.method)
+	for _, seg := range strings.Split(rt.path, "/") {
+		switch {
+		case seg == "":
+		case strings.HasPrefix(seg, ":"):
+			id += "By" + exportedName(seg[1:])
+		default:
+			id += exportedName(seg)
+		}
+	}
+	return id
+}
+
+func (b *specBuilder) queryParameters(t reflect.Type) []object {
+	params := make([]object, 0)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("form"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		params = append(params, object{"name": name, "in": "query", "schema": b.schemaOf(f.Type)})
+	}
+	return params
+}
+
+// schemaOf returns the schema of the type. Named structs are added to the
+// components and referenced.
+func (b *specBuilder) schemaOf(t reflect.Type) object {
+	if o, ok := schemaOverrides[t]; ok {
+		return b.namedSchema(t.Name(), o)
+	}
+
+	if values, ok := enumValues[t]; ok {
+		return object{"type": "string", "enum": values}
+	}
+
+	switch {
+	case t == timeType:
+		return object{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return object{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return b.schemaOf(t.Elem())
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return object{"type": "integer", "format": "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return object{"type": "integer", "format": "int64", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return object{"type": "array", "items": b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return b.namedSchema(t.Name(), t)
+	}
+	return object{}
+}
+
+func (b *specBuilder) namedSchema(name string, t reflect.Type) object {
+	name = exportedName(name)
+	if _, ok := b.schemas[name]; !ok {
+		b.schemas[name] = object{} // placeholder for the recursive types
+		b.schemas[name] = b.structSchema(t)
+	}
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+func (b *specBuilder) structSchema(t reflect.Type) object {
+	properties := object{}
+	required := make([]string, 0)
+	b.addFields(t, properties, &required)
+
+	s := object{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// addFields collects the JSON fields of the struct, flattening the embedded structs
+func (b *specBuilder) addFields(t reflect.Type, properties object, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			b.addFields(f.Type, properties, required)
+			continue
+		}
+		if f.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s := b.schemaOf(f.Type)
+		if example, ok := f.Tag.Lookup("example"); ok {
+			s = withExample(s, f.Type, example)
+		}
+		properties[name] = s
+
+		for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+			switch {
+			case rule == "required":
+				*required = append(*required, name)
+			case strings.HasPrefix(rule, "min="):
+				n, _ := strconv.Atoi(strings.TrimPrefix(rule, "min="))
+				if f.Type.Kind() == reflect.Slice {
+					s["minItems"] = n
+				} else {
+					s["minimum"] = n
+				}
+			case strings.HasPrefix(rule, "max="):
+				n, _ := strconv.Atoi(strings.TrimPrefix(rule, "max="))
+				if f.Type.Kind() == reflect.Slice {
+					s["maxItems"] = n
+				} else {
+					s["maximum"] = n
+				}
+			}
+		}
+	}
+}
+
+func withExample(s object, t reflect.Type, example string) object {
+	if _, ref := s["$ref"]; ref {
+		// siblings of $ref are ignored, so wrap it
+		s = object{"allOf": []object{s}}
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Uint:
+		n, _ := strconv.Atoi(example)
+		s["example"] = n
+	case reflect.Slice:
+		s["example"] = strings.Split(example, ",")
+	default:
+		s["example"] = example
+	}
+	return s
+}
+
+func exportedName(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// handleOpenAPI serves the OpenAPI document of the gateway
+func handleOpenAPI() gin.HandlerFunc {
+	spec := openAPISpec()
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	}
+}
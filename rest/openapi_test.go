@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var update = flag.Bool("update", false, "rewrite openapi.json")
+
+// TestOpenAPIDocument fails when openapi.json no longer matches the routes.
+// Run go test -update to regenerate it.
+func TestOpenAPIDocument(t *testing.T) {
+	dat, err := json.MarshalIndent(openAPISpec(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dat = append(dat, '\n')
+
+	if *update {
+		if err := ioutil.WriteFile("openapi.json", dat, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	published, err := ioutil.ReadFile("openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dat, published) {
+		t.Fatal("openapi.json is out of date, run go test -update")
+	}
+}
+
+func TestRoutesDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg = &config{}
+
+	paths := openAPISpec()["paths"].(object)
+	for _, r := range newRouter().Routes() {
+		if r.Path == "/openapi.json" {
+			continue
+		}
+
+		path := pathParamPattern.ReplaceAllString(r.Path, "{$1}")
+		item, ok := paths[path].(object)
+		if !ok {
+			t.Errorf("%s %s: not documented", r.Method, r.Path)
+			continue
+		}
+		if _, ok := item[strings.ToLower(r.Method)]; !ok {
+			t.Errorf("%s %s: not documented", r.Method, r.Path)
+		}
+	}
+
+	for _, rt := range routes() {
+		if rt.doc.summary == "" {
+			t.Errorf("%s %s: summary not set", rt.method, rt.path)
+		}
+		if rt.doc.request != nil && reflect.TypeOf(rt.doc.request).Kind() != reflect.Struct {
+			t.Errorf("%s %s: request is not a struct", rt.method, rt.path)
+		}
+	}
+}
+
+func TestOperationIdsUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, rt := range routes() {
+		id := operationId(rt)
+		if seen[id] {
+			t.Errorf("duplicate operation id: %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+// TestHandlerResponses type-checks the handlers and compares the request types they
+// bind and the responses they send with the documented ones. Error responses are
+// documented by the 4XX and 5XX ranges.
+func TestHandlerResponses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("type-checks the package")
+	}
+
+	a := analyseHandlers(t)
+	for _, rt := range routes() {
+		name := runtime.FuncForPC(reflect.ValueOf(rt.handler).Pointer()).Name()
+		name = strings.Split(name, ".")[1]
+		requests, responses := a.handler(name)
+
+		want := make(map[string]bool)
+		if rt.doc.request != nil {
+			want[docType(rt.doc.request)] = true
+		}
+		if !reflect.DeepEqual(requests, want) {
+			t.Errorf("%s %s: binds %v, documented %v", rt.method, rt.path, keys(requests), keys(want))
+		}
+
+		documented := successResponses(rt.doc)
+		for status, types := range responses {
+			if status >= 400 && status < 600 {
+				continue
+			}
+			response, ok := documented[status]
+			if !ok {
+				t.Errorf("%s %s: status %d not documented", rt.method, rt.path, status)
+				continue
+			}
+			if want := docType(response); len(types) != 1 || !types[want] {
+				t.Errorf("%s %s: status %d sends %v, documented %s", rt.method, rt.path, status, keys(types), want)
+			}
+		}
+		for status := range documented {
+			if _, ok := responses[status]; !ok {
+				t.Errorf("%s %s: documented status %d never sent", rt.method, rt.path, status)
+			}
+		}
+	}
+}
+
+// docType names a documented type the way the type checker does
+func docType(v interface{}) string {
+	switch {
+	case v == nil:
+		return "gin.H"
+	case reflect.TypeOf(v) == bytesType:
+		return "[]byte"
+	}
+	return reflect.TypeOf(v).String()
+}
+
+func keys(m map[string]bool) []string {
+	var s []string
+	for k := range m {
+		s = append(s, k)
+	}
+	sort.Strings(s)
+	return s
+}
+
+type handlerAnalysis struct {
+	info  *types.Info
+	funcs map[string]*ast.FuncDecl
+}
+
+func analyseHandlers(t *testing.T) *handlerAnalysis {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &handlerAnalysis{
+		info: &types.Info{
+			Types: make(map[ast.Expr]types.TypeAndValue),
+			Uses:  make(map[*ast.Ident]types.Object),
+		},
+		funcs: make(map[string]*ast.FuncDecl),
+	}
+	var files []*ast.File
+	for _, f := range pkgs["main"].Files {
+		files = append(files, f)
+		for _, d := range f.Decls {
+			if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv == nil {
+				a.funcs[fn.Name.Name] = fn
+			}
+		}
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("main", fset, files, a.info); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// handler returns the request types bound and the response types sent by status
+// by the handler, following the calls to the functions of the package
+func (a *handlerAnalysis) handler(name string) (map[string]bool, map[int]map[string]bool) {
+	requests := make(map[string]bool)
+	responses := make(map[int]map[string]bool)
+	a.walk(name, requests, responses, make(map[string]bool))
+	return requests, responses
+}
+
+func (a *handlerAnalysis) walk(name string, requests map[string]bool, responses map[int]map[string]bool, visited map[string]bool) {
+	fn, ok := a.funcs[name]
+	if !ok || visited[name] {
+		return
+	}
+	visited[name] = true
+
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		switch fun := call.Fun.(type) {
+		case *ast.Ident:
+			if _, ok := a.info.Uses[fun].(*types.Func); !ok {
+				return true
+			}
+			if fun.Name == "bindAccountRequest" {
+				a.bind(call.Args[1], requests)
+			}
+			a.walk(fun.Name, requests, responses, visited)
+		case *ast.SelectorExpr:
+			if a.typeOf(fun.X) != "*gin.Context" {
+				return true
+			}
+			switch fun.Sel.Name {
+			case "ShouldBindJSON", "ShouldBindQuery":
+				a.bind(call.Args[0], requests)
+			case "JSON", "AbortWithStatusJSON":
+				a.send(call.Args[0], a.typeOf(call.Args[1]), responses)
+			case "Data":
+				a.send(call.Args[0], "[]byte", responses)
+			}
+		}
+		return true
+	})
+}
+
+func (a *handlerAnalysis) typeOf(e ast.Expr) string {
+	return types.TypeString(a.info.Types[e].Type, func(p *types.Package) string { return p.Name() })
+}
+
+// bind records the type of a request bound through a pointer
+func (a *handlerAnalysis) bind(arg ast.Expr, requests map[string]bool) {
+	if p, ok := a.info.Types[arg].Type.(*types.Pointer); ok {
+		requests[types.TypeString(p.Elem(), func(p *types.Package) string { return p.Name() })] = true
+	}
+}
+
+// send records a response whose status is a constant
+func (a *handlerAnalysis) send(arg ast.Expr, typ string, responses map[int]map[string]bool) {
+	tv := a.info.Types[arg]
+	if tv.Value == nil {
+		return
+	}
+	status, _ := constant.Int64Val(tv.Value)
+	if responses[int(status)] == nil {
+		responses[int(status)] = make(map[string]bool)
+	}
+	responses[int(status)][strings.TrimPrefix(typ, "*")] = true
+}
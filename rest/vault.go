@@ -0,0 +1,317 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	vaultSaltLength   = 16
+	vaultNonceLength  = 24
+	vaultHandlePrefix = "acct_"
+	vaultAllAccounts  = "*"
+	vaultCanary       = "bitmark-sdk-rest vault"
+)
+
+var (
+	errVaultAccountNotFound = errors.New("account not found")
+	errVaultDecryption      = errors.New("unable to decrypt the account")
+	errVaultPassphrase      = errors.New("wrong vault passphrase")
+)
+
+// vaultRecord is the file stored for each account. Only the account number is kept in clear.
+type vaultRecord struct {
+	AccountNumber string `json:"account_number"`
+	Nonce         string `json:"nonce"`
+	Seed          string `json:"seed"`
+}
+
+// vaultCanaryRecord is the known plaintext sealed to check the passphrase
+type vaultCanaryRecord struct {
+	Nonce  string `json:"nonce"`
+	Canary string `json:"canary"`
+}
+
+// vault keeps the accounts of the gateway encrypted at rest. Callers refer to an account
+// by its opaque handle, and an API key may only use the accounts granted to it.
+type vault struct {
+	sync.Mutex
+	dir    string
+	key    [32]byte
+	grants map[string][]string
+}
+
+// openVault opens the vault under dir, deriving the encryption key from the passphrase
+func openVault(dir, passphrase string) (*vault, error) {
+	if passphrase == "" {
+		return nil, errors.New("vault passphrase not set")
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "accounts"), 0700); err != nil {
+		return nil, err
+	}
+
+	salt, err := readOrCreateSalt(filepath.Join(dir, "salt"))
+	if err != nil {
+		return nil, err
+	}
+
+	derived, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &vault{dir: dir, grants: make(map[string][]string)}
+	copy(v.key[:], derived)
+
+	if err := v.checkPassphrase(); err != nil {
+		return nil, err
+	}
+
+	dat, err := ioutil.ReadFile(v.grantsPath())
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(dat, &v.grants); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+func readOrCreateSalt(path string) ([]byte, error) {
+	salt, err := ioutil.ReadFile(path)
+	if err == nil {
+		return salt, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	salt = make([]byte, vaultSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, ioutil.WriteFile(path, salt, 0600)
+}
+
+// store encrypts the seed of the account, grants it to the API key and returns its handle
+func (v *vault) store(acct *sdk.Account, apiKey string) (string, error) {
+	handle, err := newHandle()
+	if err != nil {
+		return "", err
+	}
+
+	nonce, sealed, err := v.seal([]byte(acct.Seed()))
+	if err != nil {
+		return "", err
+	}
+
+	dat, err := json.Marshal(vaultRecord{
+		AccountNumber: acct.AccountNumber(),
+		Nonce:         nonce,
+		Seed:          sealed,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	v.Lock()
+	defer v.Unlock()
+
+	if err := ioutil.WriteFile(v.accountPath(handle), dat, 0600); err != nil {
+		return "", err
+	}
+
+	v.grants[apiKey] = append(v.grants[apiKey], handle)
+	return handle, v.saveGrants()
+}
+
+// open decrypts the account of the handle
+func (v *vault) open(handle string) (*sdk.Account, error) {
+	r, err := v.record(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := v.unseal(r.Nonce, r.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.RestoreAccountFromSeed(string(seed))
+}
+
+// seal encrypts the plaintext by the vault key, returning the hex of the nonce and the ciphertext
+func (v *vault) seal(plain []byte) (string, string, error) {
+	var nonce [vaultNonceLength]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", "", err
+	}
+	sealed := secretbox.Seal(nil, plain, &nonce, &v.key)
+	return hex.EncodeToString(nonce[:]), hex.EncodeToString(sealed), nil
+}
+
+func (v *vault) unseal(nonceHex, sealedHex string) ([]byte, error) {
+	nonce, err := hex.DecodeString(nonceHex)
+	if err != nil || len(nonce) != vaultNonceLength {
+		return nil, errVaultDecryption
+	}
+	sealed, err := hex.DecodeString(sealedHex)
+	if err != nil {
+		return nil, errVaultDecryption
+	}
+
+	var n [vaultNonceLength]byte
+	copy(n[:], nonce)
+	plain, ok := secretbox.Open(nil, sealed, &n, &v.key)
+	if !ok {
+		return nil, errVaultDecryption
+	}
+	return plain, nil
+}
+
+// checkPassphrase opens the canary sealed when the vault was created, so a wrong
+// passphrase is reported when the vault is opened. The canary of a vault created
+// without one is sealed once an account of the vault opens.
+func (v *vault) checkPassphrase() error {
+	dat, err := ioutil.ReadFile(v.canaryPath())
+	if err == nil {
+		var c vaultCanaryRecord
+		if err := json.Unmarshal(dat, &c); err != nil {
+			return err
+		}
+		plain, err := v.unseal(c.Nonce, c.Canary)
+		if err != nil || string(plain) != vaultCanary {
+			return errVaultPassphrase
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	handles, err := v.handles()
+	if err != nil {
+		return err
+	}
+	if len(handles) > 0 {
+		r, err := v.record(handles[0])
+		if err != nil {
+			return err
+		}
+		if _, err := v.unseal(r.Nonce, r.Seed); err != nil {
+			return errVaultPassphrase
+		}
+	}
+
+	nonce, sealed, err := v.seal([]byte(vaultCanary))
+	if err != nil {
+		return err
+	}
+	if dat, err = json.Marshal(vaultCanaryRecord{nonce, sealed}); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(v.canaryPath(), dat, 0600)
+}
+
+func (v *vault) record(handle string) (*vaultRecord, error) {
+	if !validHandle(handle) {
+		return nil, errVaultAccountNotFound
+	}
+
+	dat, err := ioutil.ReadFile(v.accountPath(handle))
+	if os.IsNotExist(err) {
+		return nil, errVaultAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var r vaultRecord
+	if err := json.Unmarshal(dat, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// handles returns the handles of all the accounts in the vault
+func (v *vault) handles() ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(v.dir, "accounts"))
+	if err != nil {
+		return nil, err
+	}
+
+	handles := make([]string, 0, len(files))
+	for _, f := range files {
+		if validHandle(f.Name()) {
+			handles = append(handles, f.Name())
+		}
+	}
+	sort.Strings(handles)
+	return handles, nil
+}
+
+// granted returns the handles created by the API key
+func (v *vault) granted(apiKey string) []string {
+	v.Lock()
+	defer v.Unlock()
+
+	return append([]string(nil), v.grants[apiKey]...)
+}
+
+func (v *vault) saveGrants() error {
+	dat, err := json.Marshal(v.grants)
+	if err != nil {
+		return err
+	}
+
+	tmp := v.grantsPath() + ".tmp"
+	if err := ioutil.WriteFile(tmp, dat, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, v.grantsPath())
+}
+
+func (v *vault) accountPath(handle string) string {
+	return filepath.Join(v.dir, "accounts", handle)
+}
+
+func (v *vault) canaryPath() string {
+	return filepath.Join(v.dir, "canary")
+}
+
+func (v *vault) grantsPath() string {
+	return filepath.Join(v.dir, "grants.json")
+}
+
+func newHandle() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return vaultHandlePrefix + hex.EncodeToString(b), nil
+}
+
+// validHandle guards the file lookups against path traversal
+func validHandle(handle string) bool {
+	if len(handle) != len(vaultHandlePrefix)+32 || handle[:len(vaultHandlePrefix)] != vaultHandlePrefix {
+		return false
+	}
+	_, err := hex.DecodeString(handle[len(vaultHandlePrefix):])
+	return err == nil
+}
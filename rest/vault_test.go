@@ -0,0 +1,156 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sdk "github.com/bitmark-inc/bitmark-sdk-go"
+)
+
+// newTestClient points the client to an API server answering every request with {}
+func newTestClient() *httptest.Server {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	client = sdk.NewClient(&sdk.Config{Network: "testnet", APIEndpoint: api.URL})
+	return api
+}
+
+// newTestVault opens a vault in a temporary directory, removed with the API server by cleanup
+func newTestVault(t *testing.T) (v *vault, dir string, cleanup func()) {
+	api := newTestClient()
+
+	dir, err := ioutil.TempDir("", "vault")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup = func() {
+		api.Close()
+		os.RemoveAll(dir)
+	}
+
+	v, err = openVault(dir, "correct horse battery staple")
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	return v, dir, cleanup
+}
+
+func TestVaultStoreAndOpen(t *testing.T) {
+	v, dir, cleanup := newTestVault(t)
+	defer cleanup()
+
+	acct, err := client.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handle, err := v.store(acct, "ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validHandle(handle) {
+		t.Fatalf("invalid handle %s", handle)
+	}
+
+	dat, err := ioutil.ReadFile(filepath.Join(dir, "accounts", handle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(dat), acct.Seed()) {
+		t.Fatal("seed stored in clear")
+	}
+
+	opened, err := v.open(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened.AccountNumber() != acct.AccountNumber() {
+		t.Fatalf("opened %s, stored %s", opened.AccountNumber(), acct.AccountNumber())
+	}
+
+	if granted := v.granted("ops"); len(granted) != 1 || granted[0] != handle {
+		t.Fatalf("granted %v", granted)
+	}
+
+	// the grants survive reopening
+	reopened, err := openVault(dir, "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if granted := reopened.granted("ops"); len(granted) != 1 || granted[0] != handle {
+		t.Fatalf("granted after reopening %v", granted)
+	}
+}
+
+func TestVaultWrongPassphrase(t *testing.T) {
+	_, dir, cleanup := newTestVault(t)
+	defer cleanup()
+
+	if _, err := openVault(dir, "wrong passphrase"); err != errVaultPassphrase {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := openVault(dir, ""); err == nil {
+		t.Fatal("opened without a passphrase")
+	}
+}
+
+func TestVaultWithoutCanary(t *testing.T) {
+	v, dir, cleanup := newTestVault(t)
+	defer cleanup()
+
+	acct, err := client.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.store(acct, "ops"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a vault created before the canary is checked against its accounts
+	if err := os.Remove(v.canaryPath()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openVault(dir, "wrong passphrase"); err != errVaultPassphrase {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := openVault(dir, "correct horse battery staple"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(v.canaryPath()); err != nil {
+		t.Fatal("canary not sealed")
+	}
+}
+
+func TestVaultHandleTraversal(t *testing.T) {
+	v, _, cleanup := newTestVault(t)
+	defer cleanup()
+
+	for _, handle := range []string{
+		"",
+		"acct_",
+		"../salt",
+		"acct_../../grants.json",
+		"acct_5f1c0c4c2e8a4d6b9d3e7a1f0b2c4d6",
+		"acct_5f1c0c4c2e8a4d6b9d3e7a1f0b2c4d6e0",
+		"acct_5f1c0c4c2e8a4d6b9d3e7a1f0b2c4d/.",
+		"user_5f1c0c4c2e8a4d6b9d3e7a1f0b2c4d6e",
+	} {
+		if validHandle(handle) {
+			t.Errorf("%q accepted", handle)
+		}
+		if _, err := v.open(handle); err != errVaultAccountNotFound {
+			t.Errorf("%q: unexpected error: %v", handle, err)
+		}
+	}
+
+	if !validHandle("acct_5f1c0c4c2e8a4d6b9d3e7a1f0b2c4d6e") {
+		t.Error("valid handle rejected")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"io"
 
 	"golang.org/x/crypto/sha3"
@@ -24,6 +25,21 @@ func (n Network) String() string {
 	}
 }
 
+// NetworkMismatchError is returned when an account or a receiver is not in the expected network.
+// It matches ErrNetworkMismatch by errors.Is.
+type NetworkMismatchError struct {
+	Expected Network
+	Actual   Network
+}
+
+func (e *NetworkMismatchError) Error() string {
+	return fmt.Sprintf("%s: %s account used in %s environment", ErrNetworkMismatch, e.Actual, e.Expected)
+}
+
+func (e *NetworkMismatchError) Is(target error) bool {
+	return target == ErrNetworkMismatch
+}
+
 const SeedVersion1 SeedVersion = 1
 
 const (
@@ -44,6 +60,7 @@ var (
 )
 
 var (
+	ErrNetworkMismatch      = errors.New("network mismatch")
 	ErrSeedSizeMismatch     = errors.New("seed size mismatch")
 	ErrSeedHeaderMismatch   = errors.New("seed header mismatch")
 	ErrSeedChecksumMismatch = errors.New("seed checksum mismatch")
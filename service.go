@@ -2,6 +2,7 @@ package bitmarksdk
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -15,58 +16,143 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"golang.org/x/crypto/ed25519"
 )
 
+var ErrInvalidEncPubkey = errors.New("encryption public key not signed by the account")
+
 type Service struct {
-	client      *http.Client
-	apiEndpoint string
-	keyEndpoint string
+	client        *http.Client
+	apiEndpoint   string
+	keyEndpoint   string
+	assetEndpoint string
+
+	dataKeyAlgorithm string
+	keyCache         KeyCache
+	observers        []Observer
+	limiter          *RateLimiter
 }
 
 func (s *Service) newAPIRequest(method, path string, body io.Reader) (*http.Request, error) {
 	return http.NewRequest(method, s.apiEndpoint+path, body)
 }
 
+// requestSigner signs the request, again on every retry
+type requestSigner func(req *http.Request)
+
+type requestSignerKey struct{}
+
 func (s *Service) newSignedAPIRequest(method, path string, body io.Reader, acct *Account, parts ...string) (*http.Request, error) {
 	req, err := http.NewRequest(method, s.apiEndpoint+path, body)
 	if err != nil {
 		return nil, err
 	}
 
-	ts := strconv.FormatInt(time.Now().UnixNano()/1000000, 10)
-	parts = append(parts, acct.AccountNumber(), ts)
-	message := strings.Join(parts, "|")
-	sig := hex.EncodeToString(acct.AuthKey.Sign([]byte(message)))
+	var last int64
+	sign := func(r *http.Request) {
+		// a retry within the same millisecond is not mistaken for a replay
+		millis := time.Now().UnixNano() / 1000000
+		if millis <= last {
+			millis = last + 1
+		}
+		last = millis
+
+		ts := strconv.FormatInt(millis, 10)
+		message := strings.Join(append(append([]string{}, parts...), acct.AccountNumber(), ts), "|")
+		sig := hex.EncodeToString(acct.AuthKey.Sign([]byte(message)))
 
-	req.Header.Add("requester", acct.AccountNumber())
-	req.Header.Add("timestamp", ts)
-	req.Header.Add("signature", sig)
+		r.Header.Set("requester", acct.AccountNumber())
+		r.Header.Set("timestamp", ts)
+		r.Header.Set("signature", sig)
+	}
+	sign(req)
 
-	return req, nil
+	return req.WithContext(context.WithValue(req.Context(), requestSignerKey{}, requestSigner(sign))), nil
 }
 
 func (s *Service) newKeyRequest(method, path string, body io.Reader) (*http.Request, error) {
 	return http.NewRequest(method, s.keyEndpoint+path, body)
 }
 
-func (s *Service) submitRequest(req *http.Request, result interface{}) ([]byte, error) {
+// do sends the request of the operation and reads the response body, notifying the observers
+func (s *Service) do(op string, req *http.Request) (*http.Response, []byte, error) {
+	e := &RequestEvent{Operation: op, Method: req.Method, Path: req.URL.Path}
+	s.observeStart(e)
+	start := time.Now()
+
+	resp, data, err := s.send(op, req, e)
+	if resp != nil {
+		e.StatusCode = resp.StatusCode
+	}
+	if err == nil && resp.StatusCode/100 != 2 {
+		err = parseServiceError(resp.StatusCode, data)
+	}
+
+	e.Latency = time.Since(start)
+	s.observeFinish(e, err)
+	return resp, data, err
+}
+
+// send sends the request within the rate limits, and retries it on 429 responses.
+// A signed request is signed again with a new timestamp for every retry.
+func (s *Service) send(op string, req *http.Request, e *RequestEvent) (*http.Response, []byte, error) {
+	if s.limiter == nil {
+		return s.roundTrip(req)
+	}
+
+	class := endpointClass(op)
+	for {
+		release, err := s.limiter.acquire(req.Context(), class)
+		if err != nil {
+			return nil, nil, err
+		}
+		if sign, ok := req.Context().Value(requestSignerKey{}).(requestSigner); ok && e.Retries > 0 {
+			// the timestamp of the first attempt may be stale after the pause
+			sign(req)
+		}
+		resp, data, err := s.roundTrip(req)
+		release()
+		s.limiter.observe(class, resp)
+
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || e.Retries >= s.limiter.MaxRetries {
+			return resp, data, err
+		}
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return resp, data, err
+			}
+		} else if req.Body != nil {
+			return resp, data, err
+		}
+		e.Retries++
+	}
+}
+
+func (s *Service) roundTrip(req *http.Request) (*http.Response, []byte, error) {
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	return resp, data, err
+}
+
+func parseServiceError(statusCode int, data []byte) error {
+	var se ServiceError
+	if e := json.Unmarshal(data, &se); e != nil {
+		return fmt.Errorf("unexpected response: %s", string(data))
 	}
+	se.StatusCode = statusCode
+	return &se
+}
 
-	if resp.StatusCode/100 != 2 {
-		var se ServiceError
-		if e := json.Unmarshal(data, &se); e != nil {
-			return nil, fmt.Errorf("unexpected response: %s", string(data))
-		}
-		return nil, &se
+func (s *Service) submitRequest(op string, req *http.Request, result interface{}) ([]byte, error) {
+	_, data, err := s.do(op, req)
+	if err != nil {
+		return nil, err
 	}
 
 	if result != nil {
@@ -93,23 +179,23 @@ func (s *Service) uploadAsset(acct *Account, af *AssetFile) error {
 	switch af.Accessibility {
 	case Public:
 		if _, e := fileWriter.Write(af.Content); e != nil {
-			return err
+			return e
 		}
 	case Private:
-		dataKey, e := NewDataKey()
+		dataKey, e := NewDataKeyWithAlgorithm(s.dataKeyAlgorithm)
 		if e != nil {
-			return err
+			return e
 		}
 		encryptedContent, e := dataKey.Encrypt(af.Content)
 		if e != nil {
-			return err
+			return e
 		}
 		sessData, e := createSessionData(acct, dataKey, acct.EncrKey.PublicKeyBytes())
 		if e != nil {
-			return err
+			return e
 		}
 		if _, e := fileWriter.Write(encryptedContent); e != nil {
-			return err
+			return e
 		}
 		bodyWriter.WriteField("session_data", sessData.String())
 	}
@@ -122,7 +208,7 @@ func (s *Service) uploadAsset(acct *Account, af *AssetFile) error {
 	req, _ := s.newSignedAPIRequest("POST", "/v1/assets", body, acct, "uploadAsset", af.Id())
 	req.Header.Set("Content-Type", bodyWriter.FormDataContentType())
 
-	_, err = s.submitRequest(req, nil)
+	_, err = s.submitRequest("uploadAsset", req, nil)
 	return err
 }
 
@@ -130,7 +216,7 @@ func (s *Service) getAssetAccess(acct *Account, bitmarkId string) (*accessByOwne
 	req, _ := s.newSignedAPIRequest("GET", fmt.Sprintf("/v1/bitmarks/%s/asset", bitmarkId), nil, acct, "downloadAsset", bitmarkId)
 
 	var result accessByOwnership
-	if _, err := s.submitRequest(req, &result); err != nil {
+	if _, err := s.submitRequest("getAssetAccess", req, &result); err != nil {
 		return nil, err
 	}
 
@@ -138,12 +224,14 @@ func (s *Service) getAssetAccess(acct *Account, bitmarkId string) (*accessByOwne
 }
 
 func (s *Service) getAssetContent(url string) (string, []byte, error) {
+	if strings.HasPrefix(url, "/") {
+		url = s.assetEndpoint + url
+	}
 	req, _ := http.NewRequest("GET", url, nil)
-	resp, err := s.client.Do(req)
+	resp, data, err := s.do("getAssetContent", req)
 	if err != nil {
 		return "", nil, err
 	}
-	defer resp.Body.Close()
 
 	if resp.Header.Get("Content-Disposition") == "" {
 		return "", nil, errors.New("Missing header Content-Disposition")
@@ -151,17 +239,13 @@ func (s *Service) getAssetContent(url string) (string, []byte, error) {
 	_, params, _ := mime.ParseMediaType(resp.Header["Content-Disposition"][0])
 	filename := params["filename"]
 
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", nil, err
-	}
-
 	return filename, data, nil
 }
 
 func (s *Service) createIssueTx(asset *AssetRecord, issues []*IssueRecord) ([]string, error) {
-	b := map[string]interface{}{
-		"issues": issues,
+	b := map[string]interface{}{}
+	if len(issues) > 0 {
+		b["issues"] = issues
 	}
 	if asset != nil {
 		b["assets"] = []*AssetRecord{asset}
@@ -170,7 +254,7 @@ func (s *Service) createIssueTx(asset *AssetRecord, issues []*IssueRecord) ([]st
 	req, _ := s.newAPIRequest("POST", "/v1/issue", body)
 
 	result := make([]transaction, 0)
-	if _, err := s.submitRequest(req, &result); err != nil {
+	if _, err := s.submitRequest("issue", req, &result); err != nil {
 		return nil, err
 	}
 
@@ -188,7 +272,7 @@ func (s *Service) createTransferTx(record *TransferRecord) (string, error) {
 	req, _ := s.newAPIRequest("POST", "/v2/transfer", body)
 
 	result := make([]transaction, 0)
-	if _, err := s.submitRequest(req, &result); err != nil {
+	if _, err := s.submitRequest("transfer", req, &result); err != nil {
 		return "", err
 	}
 
@@ -202,7 +286,7 @@ func (s *Service) createCountersignTransferTx(record *CountersignedTransferRecor
 	req, _ := s.newAPIRequest("POST", "/v1/transfer", body)
 
 	result := make([]transaction, 0)
-	if _, err := s.submitRequest(req, &result); err != nil {
+	if _, err := s.submitRequest("countersignTransfer", req, &result); err != nil {
 		return "", err
 	}
 
@@ -219,7 +303,7 @@ func (s *Service) submitTransferOffer(acct *Account, record *TransferOfferRecord
 	req, _ := s.newSignedAPIRequest("POST", "/v2/transfer_offers", body, acct, "transferOffer", record.String())
 
 	var result map[string]string
-	if _, err := s.submitRequest(req, &result); err != nil {
+	if _, err := s.submitRequest("submitTransferOffer", req, &result); err != nil {
 		return "", err
 	}
 
@@ -233,20 +317,47 @@ func (s *Service) getTransferOffer(acct *Account, offerId string) (*TransferOffe
 		Offer *TransferOffer `json:"offer"`
 	}
 
-	if _, err := s.submitRequest(req, &result); err != nil {
+	if _, err := s.submitRequest("getTransferOffer", req, &result); err != nil {
 		return nil, err
 	}
 
 	return result.Offer, nil
 }
 
-func (s *Service) completeTransferOffer(acct *Account, offerId, action, countersignature string) (string, error) {
+func (s *Service) listTransferOffers(acct *Account, direction TransferOfferDirection, status TransferOfferStatus) ([]*TransferOffer, error) {
+	v := url.Values{}
+	v.Set("requester", acct.AccountNumber())
+	switch direction {
+	case OfferIncoming:
+		v.Set("to", acct.AccountNumber())
+	case OfferOutgoing:
+		v.Set("from", acct.AccountNumber())
+	}
+	if status != "" {
+		v.Set("status", string(status))
+	}
+	req, _ := s.newAPIRequest("GET", "/v2/transfer_offers?"+v.Encode(), nil)
+
+	var result struct {
+		Offers []*TransferOffer `json:"offers"`
+	}
+	if _, err := s.submitRequest("listTransferOffers", req, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Offers, nil
+}
+
+func (s *Service) completeTransferOffer(acct *Account, offerId string, action TransferOfferAction, countersignature string) (string, error) {
+	reply := map[string]string{
+		"action": string(action),
+	}
+	if countersignature != "" {
+		reply["countersignature"] = countersignature
+	}
 	body := toJSONRequestBody(map[string]interface{}{
-		"id": offerId,
-		"reply": map[string]string{
-			"action":           action,
-			"countersignature": countersignature,
-		},
+		"id":    offerId,
+		"reply": reply,
 	})
 
 	req, _ := s.newSignedAPIRequest("PATCH", "/v2/transfer_offers", body, acct, "transferOffer", "patch")
@@ -255,7 +366,7 @@ func (s *Service) completeTransferOffer(acct *Account, offerId, action, counters
 		TxId string `json:"tx_id"`
 	}
 
-	if _, err := s.submitRequest(req, &result); err != nil {
+	if _, err := s.submitRequest("completeTransferOffer", req, &result); err != nil {
 		return "", err
 	}
 
@@ -270,7 +381,7 @@ func (s *Service) addSessionData(acct *Account, bitmarkId, receiver string, data
 	})
 	req, _ := s.newSignedAPIRequest("POST", "/v2/session", body, acct, "updateSession", data.String())
 
-	_, err := s.submitRequest(req, nil)
+	_, err := s.submitRequest("addSessionData", req, nil)
 	return err
 }
 
@@ -282,21 +393,47 @@ func (s *Service) registerEncPubkey(acct *Account) error {
 	})
 	req, _ := s.newAPIRequest("POST", fmt.Sprintf("/v1/encryption_keys/%s", acct.AccountNumber()), body)
 
-	_, err := s.submitRequest(req, nil)
+	_, err := s.submitRequest("registerEncPubkey", req, nil)
 	return err
 }
 
+// getEncPubkey returns the encryption public key of the account,
+// which must be signed by the auth key of the account
 func (s *Service) getEncPubkey(acctNo string) ([]byte, error) {
+	if s.keyCache != nil {
+		if key, ok := s.keyCache.Get(acctNo); ok {
+			return key, nil
+		}
+	}
+
+	owner, err := ParseAccountNumber(acctNo)
+	if err != nil {
+		return nil, err
+	}
+
 	req, _ := s.newKeyRequest("GET", fmt.Sprintf("/%s", acctNo), nil)
 
 	var result struct {
-		Key string `json:"encryption_pubkey"`
+		Key       string `json:"encryption_pubkey"`
+		Signature string `json:"signature"`
 	}
-	if _, err := s.submitRequest(req, &result); err != nil {
+	if _, err := s.submitRequest("getEncPubkey", req, &result); err != nil {
 		return nil, err
 	}
 
-	return hex.DecodeString(result.Key)
+	key, err := hex.DecodeString(result.Key)
+	if err != nil {
+		return nil, ErrInvalidEncPubkey
+	}
+	sig, err := hex.DecodeString(result.Signature)
+	if err != nil || !ed25519.Verify(owner.PublicKey, key, sig) {
+		return nil, ErrInvalidEncPubkey
+	}
+
+	if s.keyCache != nil {
+		s.keyCache.Set(acctNo, key)
+	}
+	return key, nil
 }
 
 func (s *Service) queryBitmarks(filter *BitmarkFilter) ([]*Bitmark, error) {
@@ -306,7 +443,7 @@ func (s *Service) queryBitmarks(filter *BitmarkFilter) ([]*Bitmark, error) {
 		Bitmarks []*Bitmark `json:"bitmarks"`
 		Assets   []*Asset   `json:"assets"`
 	}
-	if _, err := s.submitRequest(req, &result); err != nil {
+	if _, err := s.submitRequest("queryBitmarks", req, &result); err != nil {
 		return nil, err
 	}
 
@@ -324,24 +461,64 @@ func (s *Service) queryBitmarks(filter *BitmarkFilter) ([]*Bitmark, error) {
 	return result.Bitmarks, nil
 }
 
-func (s *Service) getBitmark(bitmarkId string) (*Bitmark, error) {
+// getBitmark queries the bitmark, in its pending state if pending is set
+func (s *Service) getBitmark(bitmarkId string, pending bool) (*Bitmark, error) {
 	v := url.Values{}
 	v.Set("provenance", "true")
 	v.Add("asset", strconv.FormatBool(true))
-	v.Add("pending", strconv.FormatBool(false))
+	v.Add("pending", strconv.FormatBool(pending))
 	req, _ := s.newAPIRequest("GET", "/v1/bitmarks/"+bitmarkId+"?"+v.Encode(), nil)
 
 	var result struct {
 		Bitmark *Bitmark
 		Asset   *Asset
 	}
-	_, err := s.submitRequest(req, &result)
+	_, err := s.submitRequest("getBitmark", req, &result)
 	if result.Asset != nil {
 		result.Bitmark.Asset = *result.Asset
 	}
 	return result.Bitmark, err
 }
 
+func (s *Service) getTx(txId string) (*Tx, error) {
+	req, _ := s.newAPIRequest("GET", "/v1/txs/"+txId+"?pending=true", nil)
+
+	// a 404 is not found whatever the body, which may come from a proxy
+	resp, data, err := s.do("getTx", req)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrTxNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Tx *Tx `json:"tx"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("unexpected response: %s", string(data))
+	}
+	if result.Tx == nil {
+		return nil, ErrTxNotFound
+	}
+	return result.Tx, nil
+}
+
+func (s *Service) getAsset(assetId string) (*Asset, error) {
+	req, _ := s.newAPIRequest("GET", "/v1/assets/"+assetId+"?pending=true", nil)
+
+	var result struct {
+		Asset *Asset `json:"asset"`
+	}
+	if _, err := s.submitRequest("getAsset", req, &result); err != nil {
+		return nil, err
+	}
+	if result.Asset == nil {
+		return nil, errors.New("asset not found")
+	}
+	return result.Asset, nil
+}
+
 func (s *Service) updateLease(acct *Account, bitmarkId, renter string, days uint, data *SessionData) error {
 	body := toJSONRequestBody(map[string]interface{}{
 		"renter":       renter,
@@ -350,17 +527,35 @@ func (s *Service) updateLease(acct *Account, bitmarkId, renter string, days uint
 	})
 	req, _ := s.newSignedAPIRequest("POST", "/v2/leases/"+bitmarkId, body, acct, "updateLease", bitmarkId)
 
-	_, err := s.submitRequest(req, nil)
+	_, err := s.submitRequest("updateLease", req, nil)
+	return err
+}
+
+func (s *Service) revokeLease(acct *Account, bitmarkId, renter string) error {
+	req, _ := s.newSignedAPIRequest("DELETE", "/v2/leases/"+bitmarkId+"?renter="+url.QueryEscape(renter), nil, acct, "revokeLease", bitmarkId)
+
+	_, err := s.submitRequest("revokeLease", req, nil)
 	return err
 }
 
-func (s *Service) listLeases(acct *Account) ([]accessByRenting, error) {
+func (s *Service) listLeases(acct *Account) ([]*Lease, error) {
 	req, _ := s.newSignedAPIRequest("POST", "/v2/leases", nil, acct, "listLeases", "")
 
 	var result struct {
-		Leases []accessByRenting `json:"leases"`
+		Leases []*Lease `json:"leases"`
+	}
+	_, err := s.submitRequest("listLeases", req, &result)
+
+	return result.Leases, err
+}
+
+func (s *Service) listGrantedLeases(acct *Account) ([]*Lease, error) {
+	req, _ := s.newSignedAPIRequest("GET", "/v2/leases/granted", nil, acct, "listGrantedLeases", "")
+
+	var result struct {
+		Leases []*Lease `json:"leases"`
 	}
-	_, err := s.submitRequest(req, &result)
+	_, err := s.submitRequest("listGrantedLeases", req, &result)
 
 	return result.Leases, err
 }
@@ -372,8 +567,9 @@ func toJSONReqBody(data map[string]interface{}) io.Reader {
 }
 
 type ServiceError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+	StatusCode int    `json:"-"`
 }
 
 func (se *ServiceError) Error() string {
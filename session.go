@@ -2,44 +2,86 @@ package bitmarksdk
 
 import (
 	"net/http"
+	"sync"
 	"time"
 )
 
+// Session creates and restores accounts bound to the clients of their networks.
+// Only a session created by NewNetworkSession rejects the accounts of another network.
+//
+// Deprecated: use Client, which is bound to a network.
 type Session struct {
 	HTTPClient *http.Client
+
+	network *Network
+	cfg     Config
+
+	mu      sync.Mutex
+	clients map[Network]*Client
 }
 
+// Deprecated: use NewClient.
 func NewSession(c *http.Client) *Session {
 	if c == nil {
 		c = &http.Client{Timeout: 5 * time.Second}
 	}
-	return &Session{c}
+	return NewSessionWithConfig(&Config{HTTPClient: c})
 }
 
-func (sess *Session) CreateAccount(n Network) (*Account, error) {
-	seed, err := NewSeed(SeedVersion1, n)
-	if err != nil {
-		return nil, err
+// NewSessionWithConfig creates a session whose clients are configured by cfg.
+// cfg.Network is ignored.
+//
+// Deprecated: use NewClient.
+func NewSessionWithConfig(cfg *Config) *Session {
+	return &Session{HTTPClient: cfg.HTTPClient, cfg: *cfg, clients: make(map[Network]*Client)}
+}
+
+// NewNetworkSession creates a session which only accepts accounts of the network.
+//
+// Deprecated: use NewClient.
+func NewNetworkSession(c *http.Client, n Network) *Session {
+	sess := NewSession(c)
+	sess.network = &n
+	return sess
+}
+
+func (sess *Session) checkNetwork(n Network) error {
+	if sess.network != nil && *sess.network != n {
+		return &NetworkMismatchError{*sess.network, n}
 	}
+	return nil
+}
 
-	authKey, err := NewAuthKey(seed)
-	if err != nil {
-		return nil, err
+func (sess *Session) client(n Network) (*Client, error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if c, ok := sess.clients[n]; ok {
+		return c, nil
 	}
 
-	encrKey, err := NewEncrKey(seed)
+	cfg := sess.cfg
+	if sess.HTTPClient != nil {
+		cfg.HTTPClient = sess.HTTPClient
+	}
+	c, err := networkClient(n, cfg)
 	if err != nil {
 		return nil, err
 	}
+	sess.clients[n] = c
+	return c, nil
+}
 
-	apiClient := NewAPIClient(n, sess.HTTPClient)
+func (sess *Session) CreateAccount(n Network) (*Account, error) {
+	if err := sess.checkNetwork(n); err != nil {
+		return nil, err
+	}
 
-	account := &Account{apiClient, seed, authKey, encrKey}
-	err = account.api.registerEncPubkey(account)
+	client, err := sess.client(n)
 	if err != nil {
 		return nil, err
 	}
-	return account, nil
+	return client.CreateAccount()
 }
 
 func (sess *Session) RestoreAccountFromSeed(s string) (*Account, error) {
@@ -48,17 +90,13 @@ func (sess *Session) RestoreAccountFromSeed(s string) (*Account, error) {
 		return nil, err
 	}
 
-	authKey, err := NewAuthKey(seed)
-	if err != nil {
+	if err := sess.checkNetwork(seed.network); err != nil {
 		return nil, err
 	}
 
-	encrKey, err := NewEncrKey(seed)
+	client, err := sess.client(seed.network)
 	if err != nil {
 		return nil, err
 	}
-
-	apiClient := NewAPIClient(seed.network, sess.HTTPClient)
-
-	return &Account{apiClient, seed, authKey, encrKey}, nil
+	return client.RestoreAccountFromSeed(s)
 }
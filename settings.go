@@ -0,0 +1,427 @@
+package bitmarksdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"gopkg.in/yaml.v3"
+)
+
+const envPrefix = "BITMARK_"
+
+// Settings is the configuration of a client shared by the services, loaded by LoadConfig.
+// The keys are the same in HCL, JSON and YAML files, e.g.
+//
+//	network = "testnet"
+//	timeout = 10
+//	rate_limit "issue" {
+//	  rate = 5
+//	  burst = 10
+//	}
+type Settings struct {
+	Network     string `hcl:"network" json:"network" yaml:"network"`
+	APIEndpoint string `hcl:"api_endpoint" json:"api_endpoint" yaml:"api_endpoint"`
+	KeyEndpoint string `hcl:"key_endpoint" json:"key_endpoint" yaml:"key_endpoint"`
+
+	// Timeout of the requests in seconds
+	Timeout int `hcl:"timeout" json:"timeout" yaml:"timeout"`
+
+	// MaxRetries of the requests throttled by 429 responses, by the rate limiter
+	MaxRetries int                          `hcl:"max_retries" json:"max_retries" yaml:"max_retries"`
+	RateLimits map[string]RateLimitSettings `hcl:"rate_limit" json:"rate_limit" yaml:"rate_limit"`
+
+	Keystore string `hcl:"keystore" json:"keystore" yaml:"keystore"`
+	Proxy    string `hcl:"proxy" json:"proxy" yaml:"proxy"`
+
+	DataKeyAlgorithm        string `hcl:"data_key_algorithm" json:"data_key_algorithm" yaml:"data_key_algorithm"`
+	SkipUnknownFingerprints bool   `hcl:"skip_unknown_fingerprints" json:"skip_unknown_fingerprints" yaml:"skip_unknown_fingerprints"`
+}
+
+// RateLimitSettings is the RateLimit of an endpoint class.
+type RateLimitSettings struct {
+	Rate          float64 `hcl:"rate" json:"rate" yaml:"rate"`
+	Burst         int     `hcl:"burst" json:"burst" yaml:"burst"`
+	MaxConcurrent int     `hcl:"max_concurrent" json:"max_concurrent" yaml:"max_concurrent"`
+}
+
+// ConfigError is an invalid setting, by its key in the files.
+type ConfigError struct {
+	Key string
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("config %s: %s", e.Key, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigErrors collects every invalid setting found by Settings.Validate.
+type ConfigErrors []*ConfigError
+
+func (errs ConfigErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func DefaultSettings() *Settings {
+	return &Settings{
+		Network:    "livenet",
+		Timeout:    5,
+		MaxRetries: defaultRateLimitRetries,
+		RateLimits: make(map[string]RateLimitSettings),
+	}
+}
+
+// LoadConfig merges the defaults, the file if path is not empty, and the BITMARK_*
+// environment variables, and validates the result. The format of the file is
+// given by its extension: .hcl or .conf, .json, .yaml or .yml.
+func LoadConfig(path string) (*Settings, error) {
+	s := DefaultSettings()
+	if path != "" {
+		if err := s.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.LoadEnv(); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadFile overrides the settings by the keys set in the file. Unknown keys are rejected.
+func (s *Settings) LoadFile(path string) error {
+	dat, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".hcl", ".conf":
+		err = decodeHCL(dat, s)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(dat))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(s)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(dat))
+		dec.KnownFields(true)
+		if err = dec.Decode(s); err == io.EOF {
+			err = nil
+		}
+	default:
+		return fmt.Errorf("unknown config format: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// decodeHCL decodes the HCL file into v after checking its keys against the hcl tags
+func decodeHCL(dat []byte, v interface{}) error {
+	f, err := hcl.ParseBytes(dat)
+	if err != nil {
+		return err
+	}
+	if list, ok := f.Node.(*ast.ObjectList); ok {
+		if err := checkHCLKeys(list, reflect.TypeOf(v).Elem()); err != nil {
+			return err
+		}
+	}
+	return hcl.DecodeObject(v, f)
+}
+
+func checkHCLKeys(list *ast.ObjectList, typ reflect.Type) error {
+	for _, item := range list.Items {
+		typ := typ
+		for _, key := range item.Keys {
+			switch typ.Kind() {
+			case reflect.Struct:
+				name, _ := key.Token.Value().(string)
+				field, ok := hclField(typ, name)
+				if !ok {
+					return fmt.Errorf("unknown key %q", name)
+				}
+				typ = field.Type
+			case reflect.Map:
+				typ = typ.Elem()
+			}
+		}
+
+		if obj, ok := item.Val.(*ast.ObjectType); ok && (typ.Kind() == reflect.Struct || typ.Kind() == reflect.Map) {
+			if err := checkHCLKeys(obj.List, typ); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hclField returns the field of the struct type tagged by the HCL key
+func hclField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if strings.Split(field.Tag.Get("hcl"), ",")[0] == key {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// LoadEnv overrides the settings by the environment variables named by their keys,
+// e.g. BITMARK_API_ENDPOINT. The rate limit of a class is set by
+// BITMARK_RATE_LIMIT_<CLASS> as rate[,burst[,max_concurrent]].
+func (s *Settings) LoadEnv() error {
+	errs := make(ConfigErrors, 0)
+	lookup := func(key string) (string, bool) {
+		return os.LookupEnv(envPrefix + strings.ToUpper(key))
+	}
+	setInt := func(key string, v *int) {
+		if value, ok := lookup(key); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, &ConfigError{key, err})
+				return
+			}
+			*v = n
+		}
+	}
+
+	for key, v := range map[string]*string{
+		"network":            &s.Network,
+		"api_endpoint":       &s.APIEndpoint,
+		"key_endpoint":       &s.KeyEndpoint,
+		"keystore":           &s.Keystore,
+		"proxy":              &s.Proxy,
+		"data_key_algorithm": &s.DataKeyAlgorithm,
+	} {
+		if value, ok := lookup(key); ok {
+			*v = value
+		}
+	}
+	setInt("timeout", &s.Timeout)
+	setInt("max_retries", &s.MaxRetries)
+
+	if value, ok := lookup("skip_unknown_fingerprints"); ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, &ConfigError{"skip_unknown_fingerprints", err})
+		} else {
+			s.SkipUnknownFingerprints = b
+		}
+	}
+
+	for _, class := range []EndpointClass{EndpointIssue, EndpointTransfer, EndpointSession, EndpointQuery, EndpointKeyLookup} {
+		key := "rate_limit_" + string(class)
+		value, ok := lookup(key)
+		if !ok {
+			continue
+		}
+		limit, err := parseRateLimit(value)
+		if err != nil {
+			errs = append(errs, &ConfigError{key, err})
+			continue
+		}
+		if s.RateLimits == nil {
+			s.RateLimits = make(map[string]RateLimitSettings)
+		}
+		s.RateLimits[string(class)] = limit
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// parseRateLimit parses rate[,burst[,max_concurrent]]
+func parseRateLimit(value string) (RateLimitSettings, error) {
+	var limit RateLimitSettings
+	parts := strings.Split(value, ",")
+	if len(parts) > 3 {
+		return limit, fmt.Errorf("malformed rate limit %q", value)
+	}
+
+	var err error
+	if limit.Rate, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64); err != nil {
+		return limit, err
+	}
+	if len(parts) > 1 {
+		if limit.Burst, err = strconv.Atoi(strings.TrimSpace(parts[1])); err != nil {
+			return limit, err
+		}
+	}
+	if len(parts) > 2 {
+		if limit.MaxConcurrent, err = strconv.Atoi(strings.TrimSpace(parts[2])); err != nil {
+			return limit, err
+		}
+	}
+	return limit, nil
+}
+
+// Validate returns ConfigErrors listing every invalid setting.
+func (s *Settings) Validate() error {
+	errs := make(ConfigErrors, 0)
+	invalid := func(key string, format string, args ...interface{}) {
+		errs = append(errs, &ConfigError{key, fmt.Errorf(format, args...)})
+	}
+
+	if _, err := LookupNetwork(s.Network); err != nil {
+		errs = append(errs, &ConfigError{"network", err})
+	}
+
+	for _, kv := range [][2]string{{"api_endpoint", s.APIEndpoint}, {"key_endpoint", s.KeyEndpoint}, {"proxy", s.Proxy}} {
+		key, value := kv[0], kv[1]
+		if value == "" {
+			continue
+		}
+		u, err := url.Parse(value)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && key != "proxy") {
+			invalid(key, "malformed URL %q", redactURL(value))
+		}
+	}
+
+	if s.Timeout < 0 {
+		invalid("timeout", "negative timeout %d", s.Timeout)
+	}
+	if s.MaxRetries < 0 {
+		invalid("max_retries", "negative retries %d", s.MaxRetries)
+	}
+
+	classes := make([]string, 0, len(s.RateLimits))
+	for class := range s.RateLimits {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		limit := s.RateLimits[class]
+		switch EndpointClass(class) {
+		case EndpointIssue, EndpointTransfer, EndpointSession, EndpointQuery, EndpointKeyLookup:
+		default:
+			invalid("rate_limit."+class, "unknown endpoint class")
+			continue
+		}
+		if limit.Rate < 0 || limit.Burst < 0 || limit.MaxConcurrent < 0 {
+			invalid("rate_limit."+class, "negative limit")
+		}
+	}
+
+	if s.DataKeyAlgorithm != "" {
+		if _, err := lookupDataKeyAlgorithm(s.DataKeyAlgorithm); err != nil {
+			errs = append(errs, &ConfigError{"data_key_algorithm", err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Config returns the client config of the settings, with a new rate limiter if rate
+// limits are set. To share a limiter between clients, set the RateLimiter of their
+// configs to the one returned by RateLimiter.
+func (s *Settings) Config() (*Config, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if s.Proxy != "" {
+		proxy, err := url.Parse(s.Proxy)
+		if err != nil {
+			return nil, &ConfigError{"proxy", err}
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	cfg := &Config{
+		HTTPClient:              &http.Client{Transport: transport, Timeout: time.Duration(s.Timeout) * time.Second},
+		Network:                 s.Network,
+		APIEndpoint:             s.APIEndpoint,
+		KeyEndpoint:             s.KeyEndpoint,
+		DataKeyAlgorithm:        s.DataKeyAlgorithm,
+		SkipUnknownFingerprints: s.SkipUnknownFingerprints,
+	}
+
+	if len(s.RateLimits) > 0 {
+		cfg.RateLimiter = s.RateLimiter()
+	}
+	return cfg, nil
+}
+
+// RateLimiter returns a new rate limiter of the rate limits and the retries.
+func (s *Settings) RateLimiter() *RateLimiter {
+	limits := make(map[EndpointClass]RateLimit)
+	for class, limit := range s.RateLimits {
+		limits[EndpointClass(class)] = RateLimit{limit.Rate, limit.Burst, limit.MaxConcurrent}
+	}
+	l := NewRateLimiter(limits)
+	l.MaxRetries = s.MaxRetries
+	return l
+}
+
+// NewClient creates a client of the network of the settings.
+func (s *Settings) NewClient() (*Client, error) {
+	profile, err := LookupNetwork(s.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := s.Config()
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithProfile(profile, cfg)
+}
+
+// Redacted returns a copy of the settings without the passwords of the URLs.
+func (s *Settings) Redacted() *Settings {
+	r := *s
+	r.APIEndpoint = redactURL(s.APIEndpoint)
+	r.KeyEndpoint = redactURL(s.KeyEndpoint)
+	r.Proxy = redactURL(s.Proxy)
+	return &r
+}
+
+// String reports the effective settings in JSON with the secrets redacted.
+func (s *Settings) String() string {
+	dat, _ := json.Marshal(s.Redacted())
+	return string(dat)
+}
+
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
+		if strings.Contains(value, "@") {
+			return "xxxxx"
+		}
+		return value
+	}
+	if u.User == nil {
+		return value
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
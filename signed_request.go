@@ -0,0 +1,162 @@
+package bitmarksdk
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+var (
+	ErrRequestNotSigned = errors.New("request not signed")
+	ErrTimestampSkew    = errors.New("request timestamp out of the allowed window")
+	ErrRequestReplayed  = errors.New("request replayed")
+)
+
+// SignedRequest is the signature of a request signed by the SDK, over
+// action|resource|requester|timestamp. The body is not covered by the signature.
+type SignedRequest struct {
+	Requester string
+	Timestamp time.Time
+	Signature []byte
+
+	message string
+}
+
+// VerifySignedRequest verifies that the request is signed by its requester for the action
+// on the resource, at a timestamp within maxSkew of now. It does not detect replays
+// within the window; use RequestVerifier for that.
+func VerifySignedRequest(r *http.Request, action, resource string, maxSkew time.Duration) (*SignedRequest, error) {
+	requester := r.Header.Get("requester")
+	ts := r.Header.Get("timestamp")
+	sigHex := r.Header.Get("signature")
+	if requester == "" || ts == "" || sigHex == "" {
+		return nil, ErrRequestNotSigned
+	}
+
+	millis, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, ErrTimestampSkew
+	}
+	timestamp := time.Unix(0, millis*int64(time.Millisecond))
+	if skew := time.Since(timestamp); skew > maxSkew || skew < -maxSkew {
+		return nil, ErrTimestampSkew
+	}
+
+	an, err := ParseAccountNumber(requester)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return nil, ErrInvalidSignature
+	}
+
+	message := strings.Join([]string{action, resource, requester, ts}, "|")
+	if !ed25519.Verify(an.PublicKey, []byte(message), sig) {
+		return nil, ErrInvalidSignature
+	}
+
+	return &SignedRequest{requester, timestamp, sig, message}, nil
+}
+
+// NonceCache remembers the nonces of the accepted requests until they expire.
+type NonceCache interface {
+	// Add records the nonce, and reports false if it is already recorded.
+	Add(nonce string, expiresAt time.Time) bool
+}
+
+const nonceCachePruneInterval = time.Minute
+
+type memoryNonceCache struct {
+	sync.Mutex
+	nonces    map[string]time.Time
+	nextPrune time.Time
+}
+
+// NewNonceCache keeps the nonces in memory, dropping them when expired.
+func NewNonceCache() NonceCache {
+	return &memoryNonceCache{nonces: make(map[string]time.Time)}
+}
+
+func (c *memoryNonceCache) Add(nonce string, expiresAt time.Time) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+	if now.After(c.nextPrune) {
+		for n, exp := range c.nonces {
+			if now.After(exp) {
+				delete(c.nonces, n)
+			}
+		}
+		c.nextPrune = now.Add(nonceCachePruneInterval)
+	}
+
+	if exp, ok := c.nonces[nonce]; ok && !now.After(exp) {
+		return false
+	}
+	c.nonces[nonce] = expiresAt
+	return true
+}
+
+// RequestVerifier verifies the signed requests and rejects their replays.
+// The signed message of an accepted request is kept as its nonce until its
+// timestamp leaves the window.
+type RequestVerifier struct {
+	MaxSkew time.Duration
+	Nonces  NonceCache
+}
+
+func NewRequestVerifier(maxSkew time.Duration) *RequestVerifier {
+	return &RequestVerifier{MaxSkew: maxSkew, Nonces: NewNonceCache()}
+}
+
+func (v *RequestVerifier) Verify(r *http.Request, action, resource string) (*SignedRequest, error) {
+	sr, err := VerifySignedRequest(r, action, resource, v.MaxSkew)
+	if err != nil {
+		return nil, err
+	}
+
+	if !v.Nonces.Add(sr.message, sr.Timestamp.Add(v.MaxSkew)) {
+		return nil, ErrRequestReplayed
+	}
+	return sr, nil
+}
+
+type requesterKey struct{}
+
+// RequesterFromContext returns the account number authenticated by SignedRequestMiddleware.
+func RequesterFromContext(ctx context.Context) (string, bool) {
+	requester, ok := ctx.Value(requesterKey{}).(string)
+	return requester, ok
+}
+
+// SignedRequestMiddleware authenticates the callers of next by their signed requests.
+// scope returns the action and the resource a request is expected to be signed for.
+// Unauthenticated requests are answered with 401.
+func SignedRequestMiddleware(v *RequestVerifier, scope func(r *http.Request) (action, resource string)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			action, resource := scope(r)
+			sr, err := v.Verify(r, action, resource)
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), requesterKey{}, sr.Requester)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package bitmarksdk
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifySignedRequest(t *testing.T) {
+	acct := newTestAccount(Testnet)
+	svc := &Service{apiEndpoint: "http://localhost"}
+
+	req, _ := svc.newSignedAPIRequest("POST", "/v2/leases/bid", nil, acct, "updateLease", "bid")
+	sr, err := VerifySignedRequest(req, "updateLease", "bid", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sr.Requester != acct.AccountNumber() || time.Since(sr.Timestamp) > time.Minute {
+		t.Fatalf("unexpected signed request: %+v", sr)
+	}
+
+	if _, err := VerifySignedRequest(req, "revokeLease", "bid", time.Minute); err != ErrInvalidSignature {
+		t.Fatalf("unexpected error of another action: %v", err)
+	}
+
+	req.Header.Set("requester", newTestAccount(Testnet).AccountNumber())
+	if _, err := VerifySignedRequest(req, "updateLease", "bid", time.Minute); err != ErrInvalidSignature {
+		t.Fatalf("unexpected error of another requester: %v", err)
+	}
+
+	legacy := APIRequest{httptest.NewRequest("GET", "/", nil)}
+	legacy.Sign(acct, "listLeases", "")
+	legacy.Header.Set("timestamp", strconv.FormatInt(time.Now().Add(-2*time.Minute).UnixNano()/1000000, 10))
+	if _, err := VerifySignedRequest(legacy.Request, "listLeases", "", time.Minute); err != ErrTimestampSkew {
+		t.Fatalf("unexpected error of a stale request: %v", err)
+	}
+
+	if _, err := VerifySignedRequest(httptest.NewRequest("GET", "/", nil), "", "", time.Minute); err != ErrRequestNotSigned {
+		t.Fatalf("unexpected error of an unsigned request: %v", err)
+	}
+}
+
+func TestSignedRequestMiddleware(t *testing.T) {
+	acct := newTestAccount(Livenet)
+
+	var requester string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requester, _ = RequesterFromContext(r.Context())
+	})
+	scope := func(r *http.Request) (string, string) {
+		return "access", r.URL.Path
+	}
+	ts := httptest.NewServer(SignedRequestMiddleware(NewRequestVerifier(time.Minute), scope)(handler))
+	defer ts.Close()
+
+	svc := &Service{apiEndpoint: ts.URL}
+	req, _ := svc.newSignedAPIRequest("GET", "/files/1", nil, acct, "access", "/files/1")
+	replay := req.Clone(req.Context())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requester != acct.AccountNumber() {
+		t.Fatalf("unexpected response: %d %s", resp.StatusCode, requester)
+	}
+
+	resp, err = http.DefaultClient.Do(replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("replay accepted: %d", resp.StatusCode)
+	}
+}
+
+func TestNonceCache(t *testing.T) {
+	c := NewNonceCache()
+	if !c.Add("n1", time.Now().Add(-time.Second)) || !c.Add("n2", time.Now().Add(time.Minute)) {
+		t.Fatal("new nonce rejected")
+	}
+	if c.Add("n2", time.Now().Add(time.Minute)) {
+		t.Fatal("nonce accepted twice")
+	}
+	if !c.Add("n1", time.Now().Add(time.Minute)) {
+		t.Fatal("expired nonce not dropped")
+	}
+
+	v := &RequestVerifier{MaxSkew: time.Minute, Nonces: c}
+	acct := newTestAccount(Testnet)
+	req, _ := (&Service{}).newSignedAPIRequest("GET", "/", nil, acct, "a", "r")
+	if _, err := v.Verify(req, "a", "r"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(req, "a", "r"); !errors.Is(err, ErrRequestReplayed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -13,11 +13,3 @@ type accessByOwnership struct {
 	assetAccessMeta
 	Sender string `json:"sender"`
 }
-
-type accessByRenting struct {
-	assetAccessMeta
-	AssetId  string `json:"asset_id"`
-	Owner    string `json:"owner"`
-	Duration uint   `json:"duration"`
-	ExpTime  int64  `json:"expiration_time"`
-}
@@ -2,15 +2,49 @@ package bitmarksdk
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 )
 
+var (
+	ErrOfferBitmarkUnknown  = errors.New("bitmark of the offer is unknown")
+	ErrOfferSenderNotOwner  = errors.New("offer sender is not the bitmark owner")
+	ErrOfferStaleLink       = errors.New("offer link is not the bitmark head")
+	ErrOfferBitmarkMismatch = errors.New("embedded bitmark does not match the offer")
+	ErrOfferBitmarkPending  = errors.New("bitmark is pending")
+	ErrOfferDirection       = errors.New("unknown transfer offer direction")
+)
+
+type TransferOfferAction string
+
+const (
+	ActionAccept TransferOfferAction = "accept"
+	ActionReject TransferOfferAction = "reject"
+	ActionCancel TransferOfferAction = "cancel"
+)
+
+type TransferOfferStatus string
+
+const (
+	OfferStatusOpen      TransferOfferStatus = "open"
+	OfferStatusAccepted  TransferOfferStatus = "accepted"
+	OfferStatusRejected  TransferOfferStatus = "rejected"
+	OfferStatusCancelled TransferOfferStatus = "cancelled"
+)
+
+type TransferOfferDirection string
+
+const (
+	OfferIncoming TransferOfferDirection = "incoming"
+	OfferOutgoing TransferOfferDirection = "outgoing"
+)
+
 type TransferOffer struct {
 	Id        string              `json:"id"`
 	BitmarkId string              `json:"bitmark_id"`
 	From      string              `json:"from"`
 	To        string              `json:"to"`
-	Status    string              `json:"status"`
+	Status    TransferOfferStatus `json:"status"`
 	Record    TransferOfferRecord `json:"record"`
 	Metadata  json.RawMessage     `json:"metadata"`
 	CreatedAt time.Time           `json:"created_at"`
@@ -0,0 +1,391 @@
+package bitmarksdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+type WatchEventType string
+
+const (
+	EventBitmarkReceived   WatchEventType = "bitmark_received"
+	EventBitmarkSent       WatchEventType = "bitmark_sent"
+	EventTransferConfirmed WatchEventType = "transfer_confirmed"
+	EventOfferReceived     WatchEventType = "offer_received"
+	EventOfferCompleted    WatchEventType = "offer_completed"
+	EventLeaseGranted      WatchEventType = "lease_granted"
+	EventLeaseExpiring     WatchEventType = "lease_expiring"
+)
+
+const watchPageSize = 100
+
+// WatchEvent is emitted by a Watcher. Bitmark, Offer or Lease is set by the type of the event.
+type WatchEvent struct {
+	Type        WatchEventType
+	Account     string
+	BitmarkId   string
+	TxId        string
+	BlockNumber uint
+	Bitmark     *Bitmark
+	Offer       *TransferOffer
+	Lease       *Lease
+}
+
+// key identifies the event for the deduplication
+func (e *WatchEvent) key() string {
+	switch e.Type {
+	case EventOfferReceived, EventOfferCompleted:
+		return fmt.Sprintf("%s:%s", e.Type, e.Offer.Id)
+	case EventLeaseGranted, EventLeaseExpiring:
+		return fmt.Sprintf("%s:%s:%s:%d", e.Type, e.BitmarkId, e.Lease.Owner, e.Lease.ExpiresAt.Unix())
+	}
+	return fmt.Sprintf("%s:%s", e.Type, e.TxId)
+}
+
+// WatchCursor is the persistent progress of the watcher for an account.
+// Seen keeps the keys of the emitted events with the time they were last observed.
+type WatchCursor struct {
+	Offset     uint                 `json:"offset"`
+	PendingTxs map[string]string    `json:"pending_txs"`
+	Seen       map[string]time.Time `json:"seen"`
+}
+
+// clone copies the cursor, which is updated by a poll before it is saved
+func (c *WatchCursor) clone() *WatchCursor {
+	cloned := &WatchCursor{Offset: c.Offset, PendingTxs: make(map[string]string), Seen: make(map[string]time.Time)}
+	for txId, bitmarkId := range c.PendingTxs {
+		cloned.PendingTxs[txId] = bitmarkId
+	}
+	for key, t := range c.Seen {
+		cloned.Seen[key] = t
+	}
+	return cloned
+}
+
+type WatchState struct {
+	Accounts map[string]*WatchCursor `json:"accounts"`
+}
+
+// WatchStore persists the state of a Watcher.
+type WatchStore interface {
+	Load() (*WatchState, error)
+	Save(*WatchState) error
+}
+
+// FileWatchStore keeps the watcher state in a JSON file.
+type FileWatchStore struct {
+	Path string
+}
+
+func (s *FileWatchStore) Load() (*WatchState, error) {
+	dat, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return &WatchState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state WatchState
+	if err := json.Unmarshal(dat, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (s *FileWatchStore) Save(state *WatchState) error {
+	dat, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, dat, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
+
+type memoryWatchStore struct{}
+
+func (memoryWatchStore) Load() (*WatchState, error) { return &WatchState{}, nil }
+func (memoryWatchStore) Save(*WatchState) error     { return nil }
+
+type WatcherOptions struct {
+	// Interval between the polls, 30 seconds if not set.
+	Interval time.Duration
+
+	// ExpiringWithin is how long before the expiration EventLeaseExpiring is emitted, 24 hours if not set.
+	ExpiringWithin time.Duration
+
+	// Retention is how long the key of an event no longer observed is kept, 7 days if not set.
+	Retention time.Duration
+
+	// Backfill emits the events of the existing bitmarks, offers and leases
+	// on the first poll of an account, which are otherwise only recorded.
+	Backfill bool
+
+	// Store persists the state across restarts. The state is kept in memory if not set.
+	Store WatchStore
+
+	// OnError is called with the failures of the polls, which are retried on the next poll.
+	OnError func(accountNumber string, err error)
+}
+
+// Watcher polls the bitmarks, transfer offers and leases of a set of accounts,
+// and emits the changes as events. The events are delivered at least once:
+// the state is saved after the events of an account are delivered, and the
+// events of a poll interrupted before the save are emitted again.
+type Watcher struct {
+	client *Client
+	opts   WatcherOptions
+	events chan *WatchEvent
+
+	mu       sync.Mutex
+	accounts map[string]*Account
+	state    *WatchState
+}
+
+func (c *Client) NewWatcher(opts *WatcherOptions) (*Watcher, error) {
+	o := WatcherOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Interval <= 0 {
+		o.Interval = 30 * time.Second
+	}
+	if o.ExpiringWithin <= 0 {
+		o.ExpiringWithin = 24 * time.Hour
+	}
+	if o.Retention <= 0 {
+		o.Retention = 7 * 24 * time.Hour
+	}
+	if o.Store == nil {
+		o.Store = memoryWatchStore{}
+	}
+
+	state, err := o.Store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if state.Accounts == nil {
+		state.Accounts = make(map[string]*WatchCursor)
+	}
+
+	return &Watcher{
+		client:   c,
+		opts:     o,
+		events:   make(chan *WatchEvent),
+		accounts: make(map[string]*Account),
+		state:    state,
+	}, nil
+}
+
+// Add starts watching the account from its saved cursor.
+func (w *Watcher) Add(acct *Account) error {
+	if err := w.client.checkAccount(acct); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.accounts[acct.AccountNumber()] = acct
+	return nil
+}
+
+// Remove stops watching the account. Its cursor is kept for a later Add.
+func (w *Watcher) Remove(accountNumber string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.accounts, accountNumber)
+}
+
+// Events returns the channel of the events, closed when Run returns.
+func (w *Watcher) Events() <-chan *WatchEvent {
+	return w.events
+}
+
+// Run polls the accounts until the context is done.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.events)
+
+	for {
+		if err := w.Poll(ctx); err != nil {
+			return err
+		}
+
+		timer := time.NewTimer(w.opts.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Poll checks every account once and delivers the new events. It returns
+// an error only if the context is done or the state cannot be saved.
+func (w *Watcher) Poll(ctx context.Context) error {
+	w.mu.Lock()
+	accounts := make([]*Account, 0, len(w.accounts))
+	for _, acct := range w.accounts {
+		accounts = append(accounts, acct)
+	}
+	w.mu.Unlock()
+
+	for _, acct := range accounts {
+		if err := w.pollAccount(ctx, acct); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) pollAccount(ctx context.Context, acct *Account) error {
+	acctNo := acct.AccountNumber()
+
+	// the poll works on a copy of the cursor, replacing the saved one
+	// only after all the events are delivered
+	w.mu.Lock()
+	saved, ok := w.state.Accounts[acctNo]
+	w.mu.Unlock()
+
+	backfill := w.opts.Backfill
+	var cursor *WatchCursor
+	if !ok {
+		cursor = &WatchCursor{PendingTxs: make(map[string]string), Seen: make(map[string]time.Time)}
+	} else {
+		cursor = saved.clone()
+		backfill = true
+	}
+
+	events, err := w.collect(acct, cursor)
+	if err != nil && w.opts.OnError != nil {
+		w.opts.OnError(acctNo, err)
+	}
+	if err != nil && !ok {
+		return nil // the first sync is retried as a whole
+	}
+
+	now := time.Now()
+	for _, e := range events {
+		key := e.key()
+		if _, seen := cursor.Seen[key]; seen || !backfill {
+			cursor.Seen[key] = now
+			continue
+		}
+
+		select {
+		case w.events <- e:
+			cursor.Seen[key] = now
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for key, t := range cursor.Seen {
+		if now.Sub(t) > w.opts.Retention {
+			delete(cursor.Seen, key)
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.state.Accounts[acctNo] = cursor
+	return w.opts.Store.Save(w.state)
+}
+
+// collect gathers the events observed by this poll, including the ones already emitted.
+// The events collected before a failure are returned with the error.
+func (w *Watcher) collect(acct *Account, cursor *WatchCursor) ([]*WatchEvent, error) {
+	acctNo := acct.AccountNumber()
+	events := make([]*WatchEvent, 0)
+
+	for txId, bitmarkId := range cursor.PendingTxs {
+		tx, err := w.client.service.getTx(txId)
+		switch {
+		case err == ErrTxNotFound:
+			delete(cursor.PendingTxs, txId)
+		case err != nil:
+			return events, err
+		case tx.Status == TxConfirmed:
+			delete(cursor.PendingTxs, txId)
+			events = append(events, &WatchEvent{Type: EventTransferConfirmed, Account: acctNo, BitmarkId: bitmarkId, TxId: txId, BlockNumber: tx.BlockNumber})
+		}
+	}
+
+	for {
+		bitmarks, err := w.client.QueryBitmarks(&BitmarkFilter{
+			Owner:     acctNo,
+			OwnerSent: true,
+			Pending:   true,
+			At:        cursor.Offset + 1,
+			To:        "later",
+			Limit:     watchPageSize,
+		})
+		if err != nil {
+			return events, err
+		}
+
+		for _, bmk := range bitmarks {
+			if bmk.Offset > cursor.Offset {
+				cursor.Offset = bmk.Offset
+			}
+			if bmk.HeadId == bmk.Id {
+				continue // issued, not transferred
+			}
+
+			e := &WatchEvent{Type: EventBitmarkSent, Account: acctNo, BitmarkId: bmk.Id, TxId: bmk.HeadId, Bitmark: bmk}
+			if bmk.Owner == acctNo {
+				e.Type = EventBitmarkReceived
+			}
+			events = append(events, e)
+
+			if bmk.Status == TxPending {
+				cursor.PendingTxs[bmk.HeadId] = bmk.Id
+			}
+		}
+
+		if len(bitmarks) < watchPageSize {
+			break
+		}
+	}
+
+	incoming, err := w.client.ListTransferOffers(acct, OfferIncoming, OfferStatusOpen)
+	if err != nil {
+		return events, err
+	}
+	for _, offer := range incoming {
+		events = append(events, &WatchEvent{Type: EventOfferReceived, Account: acctNo, BitmarkId: offer.BitmarkId, Offer: offer})
+	}
+
+	outgoing, err := w.client.ListTransferOffers(acct, OfferOutgoing, "")
+	if err != nil {
+		return events, err
+	}
+	for _, offer := range outgoing {
+		if offer.Status != OfferStatusOpen {
+			events = append(events, &WatchEvent{Type: EventOfferCompleted, Account: acctNo, BitmarkId: offer.BitmarkId, TxId: offer.TxId, Offer: offer})
+		}
+	}
+
+	leases, err := w.client.ListLeases(acct)
+	if err != nil {
+		return events, err
+	}
+	for _, lease := range leases {
+		events = append(events, &WatchEvent{Type: EventLeaseGranted, Account: acctNo, BitmarkId: lease.BitmarkId, Lease: lease})
+
+		if left := time.Until(lease.ExpiresAt); left > 0 && left <= w.opts.ExpiringWithin {
+			events = append(events, &WatchEvent{Type: EventLeaseExpiring, Account: acctNo, BitmarkId: lease.BitmarkId, Lease: lease})
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,266 @@
+package bitmarksdk
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pollEvents runs a poll of the watcher and collects the events it emits
+func pollEvents(t *testing.T, w *Watcher) []*WatchEvent {
+	events := make([]*WatchEvent, 0)
+	done := make(chan error)
+	go func() {
+		done <- w.Poll(context.Background())
+	}()
+
+	for {
+		select {
+		case e := <-w.Events():
+			events = append(events, e)
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			return events
+		}
+	}
+}
+
+func eventTypes(events []*WatchEvent) string {
+	types := make([]string, len(events))
+	for i, e := range events {
+		types[i] = string(e.Type)
+	}
+	return strings.Join(types, ",")
+}
+
+func TestWatcher(t *testing.T) {
+	acct := newTestAccount(Testnet)
+	sender := newTestAccount(Testnet)
+
+	var mu sync.Mutex
+	bitmarks := []*Bitmark{
+		{Id: "issued", HeadId: "issued", Owner: acct.AccountNumber(), Status: TxConfirmed, Offset: 1},
+	}
+	offers := []*TransferOffer{}
+	leases := []*Lease{}
+	txs := map[string]*Tx{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/bitmarks", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		at, _ := strconv.Atoi(r.URL.Query().Get("at"))
+		result := make([]*Bitmark, 0)
+		for _, b := range bitmarks {
+			if b.Offset >= uint(at) {
+				result = append(result, b)
+			}
+		}
+		writeJSON(w, map[string][]*Bitmark{"bitmarks": result})
+	})
+	mux.HandleFunc("/v1/txs/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		tx, ok := txs[strings.TrimPrefix(r.URL.Path, "/v1/txs/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(w, &ServiceError{Code: 1000, Message: "not found"})
+			return
+		}
+		writeJSON(w, map[string]*Tx{"tx": tx})
+	})
+	mux.HandleFunc("/v2/transfer_offers", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		result := make([]*TransferOffer, 0)
+		for _, o := range offers {
+			if to := r.URL.Query().Get("to"); to != "" && o.To == to && o.Status == OfferStatusOpen {
+				result = append(result, o)
+			}
+			if from := r.URL.Query().Get("from"); from != "" && o.From == from {
+				result = append(result, o)
+			}
+		}
+		writeJSON(w, map[string][]*TransferOffer{"offers": result})
+	})
+	mux.HandleFunc("/v2/leases", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		writeJSON(w, map[string][]*Lease{"leases": leases})
+	})
+
+	client, ts := newTestClient(mux, nil)
+	defer ts.Close()
+
+	store := &FileWatchStore{filepath.Join(t.TempDir(), "watcher.json")}
+	newWatcher := func() *Watcher {
+		w, err := client.NewWatcher(&WatcherOptions{Store: store, ExpiringWithin: time.Hour})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Add(acct); err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+
+	w := newWatcher()
+	if events := pollEvents(t, w); len(events) != 0 {
+		t.Fatalf("unexpected events on the first poll: %s", eventTypes(events))
+	}
+
+	mu.Lock()
+	bitmarks = append(bitmarks, &Bitmark{Id: "received", HeadId: "tx1", Owner: acct.AccountNumber(), Status: TxPending, Offset: 2})
+	txs["tx1"] = &Tx{Id: "tx1", Status: TxPending}
+	offers = append(offers, &TransferOffer{Id: "offer1", BitmarkId: "offered", From: sender.AccountNumber(), To: acct.AccountNumber(), Status: OfferStatusOpen})
+	leases = append(leases, &Lease{BitmarkId: "leased", Owner: sender.AccountNumber(), Renter: acct.AccountNumber(), ExpiresAt: time.Now().Add(30 * time.Minute).Truncate(time.Second)})
+	mu.Unlock()
+
+	events := pollEvents(t, w)
+	if got := eventTypes(events); got != "bitmark_received,offer_received,lease_granted,lease_expiring" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+	if events[0].BitmarkId != "received" || events[0].TxId != "tx1" || events[0].Account != acct.AccountNumber() {
+		t.Fatalf("unexpected event: %+v", events[0])
+	}
+
+	// a restarted watcher resumes from the saved state
+	mu.Lock()
+	txs["tx1"] = &Tx{Id: "tx1", Status: TxConfirmed, BlockNumber: 42}
+	mu.Unlock()
+
+	w = newWatcher()
+	events = pollEvents(t, w)
+	if got := eventTypes(events); got != "transfer_confirmed" {
+		t.Fatalf("unexpected events after restart: %s", got)
+	}
+	if events[0].BlockNumber != 42 || events[0].BitmarkId != "received" {
+		t.Fatalf("unexpected event: %+v", events[0])
+	}
+
+	if events := pollEvents(t, w); len(events) != 0 {
+		t.Fatalf("unexpected repeated events: %s", eventTypes(events))
+	}
+}
+
+func TestWatcherBackfill(t *testing.T) {
+	acct := newTestAccount(Testnet)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/bitmarks":
+			if r.URL.Query().Get("at") != "1" {
+				writeJSON(w, map[string][]*Bitmark{"bitmarks": {}})
+				return
+			}
+			writeJSON(w, map[string][]*Bitmark{"bitmarks": {
+				{Id: "sent", HeadId: "tx1", Owner: "someone", Status: TxConfirmed, Offset: 1},
+			}})
+		default:
+			writeJSON(w, map[string]interface{}{})
+		}
+	})
+
+	client, ts := newTestClient(handler, nil)
+	defer ts.Close()
+
+	w, err := client.NewWatcher(&WatcherOptions{Backfill: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(acct); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := eventTypes(pollEvents(t, w)); got != "bitmark_sent" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+
+	if err := w.Add(newTestAccount(Livenet)); err == nil {
+		t.Fatal("account of another network added")
+	}
+}
+
+func TestWatcherInterruptedPoll(t *testing.T) {
+	acct := newTestAccount(Testnet)
+
+	var mu sync.Mutex
+	bitmarks := []*Bitmark{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/bitmarks":
+			mu.Lock()
+			defer mu.Unlock()
+
+			at, _ := strconv.Atoi(r.URL.Query().Get("at"))
+			result := make([]*Bitmark, 0)
+			for _, b := range bitmarks {
+				if b.Offset >= uint(at) {
+					result = append(result, b)
+				}
+			}
+			writeJSON(w, map[string][]*Bitmark{"bitmarks": result})
+		default:
+			writeJSON(w, map[string]interface{}{})
+		}
+	})
+
+	client, ts := newTestClient(handler, nil)
+	defer ts.Close()
+
+	store := &FileWatchStore{filepath.Join(t.TempDir(), "watcher.json")}
+	w, err := client.NewWatcher(&WatcherOptions{Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(acct); err != nil {
+		t.Fatal(err)
+	}
+	pollEvents(t, w)
+
+	mu.Lock()
+	bitmarks = append(bitmarks,
+		&Bitmark{Id: "first", HeadId: "tx1", Owner: acct.AccountNumber(), Status: TxConfirmed, Offset: 1},
+		&Bitmark{Id: "second", HeadId: "tx2", Owner: acct.AccountNumber(), Status: TxConfirmed, Offset: 2},
+	)
+	mu.Unlock()
+
+	// the poll is cancelled after the first event is delivered
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- w.Poll(ctx)
+	}()
+	if e := <-w.Events(); e.BitmarkId != "first" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the undelivered event is emitted again, by the same watcher or a restarted one
+	restarted, err := client.NewWatcher(&WatcherOptions{Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.Add(acct); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []*Watcher{w, restarted} {
+		events := pollEvents(t, w)
+		if len(events) == 0 || events[len(events)-1].BitmarkId != "second" {
+			t.Fatalf("undelivered event lost: %s", eventTypes(events))
+		}
+	}
+}